@@ -0,0 +1,209 @@
+// Package analyze attributes the bytes of generated code to the original
+// sources, directories and node_modules packages using its source map,
+// in the spirit of source-map-explorer.
+package analyze
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap"
+	"github.com/go-sourcemap/sourcemap/internal/linecol"
+)
+
+// Unmapped is the name of the tree node that holds the unmapped bytes.
+const Unmapped = "[unmapped]"
+
+// Entry is the number of bytes attributed to a source,
+// a directory or a package.
+type Entry struct {
+	Name  string `json:"name"`
+	Bytes int    `json:"bytes"`
+}
+
+// Node is a node of the tree of directories and sources.
+type Node struct {
+	Name     string  `json:"name"`
+	Bytes    int     `json:"bytes"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Result is the attribution of the generated bytes.
+// Entries are sorted by bytes in descending order.
+type Result struct {
+	TotalBytes int `json:"totalBytes"`
+	// UnmappedBytes are the bytes that are not covered by a mapping
+	// with a source, including the line terminators.
+	UnmappedBytes int `json:"unmappedBytes"`
+	// EOLBytes are the bytes of the line terminators.
+	EOLBytes int `json:"eolBytes"`
+
+	Files       []Entry `json:"files"`
+	Directories []Entry `json:"directories"`
+	Packages    []Entry `json:"packages"`
+
+	// Tree holds the sources by directory and the unmapped bytes.
+	Tree *Node `json:"tree"`
+}
+
+// Analyze attributes the bytes of the generated code to the sources
+// of the map. A generated segment runs from its mapping up to the next
+// mapping on the line or the end of the line.
+func Analyze(smap *sourcemap.Consumer, generated []byte) *Result {
+	lines := linecol.Split(string(generated))
+	mapped := make([]int, len(lines))
+	files := make(map[string]int)
+
+	var prev *sourcemap.Mapping
+	var prevStart int
+	flush := func(end int) {
+		if prev != nil && prev.Source != "" && end > prevStart {
+			files[prev.Source] += end - prevStart
+			mapped[prev.GenLine-1] += end - prevStart
+		}
+		prev = nil
+	}
+	smap.EachMapping(func(m sourcemap.Mapping) bool {
+		if m.GenLine < 1 || m.GenLine > len(lines) {
+			return true
+		}
+		start := linecol.ByteOffset(lines[m.GenLine-1], m.GenColumn)
+		if prev != nil {
+			if prev.GenLine == m.GenLine {
+				flush(start)
+			} else {
+				flush(len(lines[prev.GenLine-1]))
+			}
+		}
+		prev = &m
+		prevStart = start
+		return true
+	})
+	if prev != nil {
+		flush(len(lines[prev.GenLine-1]))
+	}
+
+	r := &Result{
+		TotalBytes: len(generated),
+	}
+	mappedBytes := 0
+	r.EOLBytes = r.TotalBytes
+	for i, l := range lines {
+		r.EOLBytes -= len(l)
+		mappedBytes += mapped[i]
+	}
+	r.UnmappedBytes = r.TotalBytes - mappedBytes
+
+	dirs := make(map[string]int)
+	packages := make(map[string]int)
+	r.Tree = &Node{Name: "", Bytes: r.TotalBytes}
+	for source, n := range files {
+		r.Files = append(r.Files, Entry{Name: source, Bytes: n})
+
+		p := treePath(source)
+		for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			dirs[dir] += n
+		}
+		if pkg := packageName(source); pkg != "" {
+			packages[pkg] += n
+		}
+		r.Tree.add(strings.Split(p, "/"), n)
+	}
+	if r.UnmappedBytes > 0 {
+		r.Tree.Children = append(r.Tree.Children, &Node{
+			Name:  Unmapped,
+			Bytes: r.UnmappedBytes,
+		})
+	}
+	r.Tree.sort()
+
+	r.Directories = entries(dirs)
+	r.Packages = entries(packages)
+	sortEntries(r.Files)
+	return r
+}
+
+func (n *Node) add(names []string, bytes int) {
+	for _, name := range names {
+		var child *Node
+		for _, c := range n.Children {
+			if c.Name == name {
+				child = c
+				break
+			}
+		}
+		if child == nil {
+			child = &Node{Name: name}
+			n.Children = append(n.Children, child)
+		}
+		child.Bytes += bytes
+		n = child
+	}
+}
+
+func (n *Node) sort() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.Bytes == b.Bytes {
+			return a.Name < b.Name
+		}
+		return a.Bytes > b.Bytes
+	})
+	for _, c := range n.Children {
+		c.sort()
+	}
+}
+
+func entries(m map[string]int) []Entry {
+	es := make([]Entry, 0, len(m))
+	for name, n := range m {
+		es = append(es, Entry{Name: name, Bytes: n})
+	}
+	sortEntries(es)
+	return es
+}
+
+func sortEntries(es []Entry) {
+	sort.Slice(es, func(i, j int) bool {
+		if es[i].Bytes == es[j].Bytes {
+			return es[i].Name < es[j].Name
+		}
+		return es[i].Bytes > es[j].Bytes
+	})
+}
+
+// treePath returns the path of the source in the tree. The host of
+// URLs is the top directory and the scheme is dropped, so
+// "webpack:///src/app.js" becomes "src/app.js".
+func treePath(source string) string {
+	p := source
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" {
+		p = u.Host + "/" + u.Path
+		if u.Opaque != "" {
+			p = u.Opaque
+		}
+	}
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// packageName returns the name of the node_modules package of the source,
+// for example "react" or "@babel/runtime". Nested packages are attributed
+// to the innermost one.
+func packageName(source string) string {
+	const dir = "node_modules/"
+	i := strings.LastIndex(source, dir)
+	if i == -1 {
+		return ""
+	}
+	parts := strings.SplitN(source[i+len(dir):], "/", 3)
+	if strings.HasPrefix(parts[0], "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
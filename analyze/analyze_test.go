@@ -0,0 +1,107 @@
+package analyze_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+	"github.com/go-sourcemap/sourcemap/analyze"
+)
+
+const generated = "abcd;\nefgh\r\nij"
+
+const mapJSON = `{
+  "version": 3,
+  "sources": ["src/app.js", "node_modules/react/index.js", "node_modules/@babel/runtime/x.js"],
+  "names": [],
+  "mappings": "AAAA,ECAA,E;CCAA"
+}`
+
+func parse(t *testing.T) *sourcemap.Consumer {
+	t.Helper()
+	smap, err := sourcemap.Parse("", []byte(mapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return smap
+}
+
+func TestAnalyze(t *testing.T) {
+	r := analyze.Analyze(parse(t), []byte(generated))
+
+	if r.TotalBytes != 14 || r.UnmappedBytes != 7 || r.EOLBytes != 3 {
+		t.Fatalf("got total=%d unmapped=%d eol=%d, wanted 14, 7 and 3",
+			r.TotalBytes, r.UnmappedBytes, r.EOLBytes)
+	}
+
+	wantedFiles := []analyze.Entry{
+		{Name: "node_modules/@babel/runtime/x.js", Bytes: 3},
+		{Name: "node_modules/react/index.js", Bytes: 2},
+		{Name: "src/app.js", Bytes: 2},
+	}
+	if !reflect.DeepEqual(r.Files, wantedFiles) {
+		t.Errorf("files: got %+v, wanted %+v", r.Files, wantedFiles)
+	}
+
+	wantedDirs := []analyze.Entry{
+		{Name: "node_modules", Bytes: 5},
+		{Name: "node_modules/@babel", Bytes: 3},
+		{Name: "node_modules/@babel/runtime", Bytes: 3},
+		{Name: "node_modules/react", Bytes: 2},
+		{Name: "src", Bytes: 2},
+	}
+	if !reflect.DeepEqual(r.Directories, wantedDirs) {
+		t.Errorf("directories: got %+v, wanted %+v", r.Directories, wantedDirs)
+	}
+
+	wantedPackages := []analyze.Entry{
+		{Name: "@babel/runtime", Bytes: 3},
+		{Name: "react", Bytes: 2},
+	}
+	if !reflect.DeepEqual(r.Packages, wantedPackages) {
+		t.Errorf("packages: got %+v, wanted %+v", r.Packages, wantedPackages)
+	}
+
+	var names []string
+	for _, n := range r.Tree.Children {
+		names = append(names, n.Name)
+	}
+	if !reflect.DeepEqual(names, []string{analyze.Unmapped, "node_modules", "src"}) {
+		t.Errorf("tree: got %q", names)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	r := analyze.Analyze(parse(t), []byte(generated))
+
+	var buf bytes.Buffer
+	if err := r.WriteHTML(&buf, "app.js"); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"<title>app.js</title>",
+		`title="react: 2 bytes (14.3%)"`,
+		`title="[unmapped]: 7 bytes (50.0%)"`,
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("%s is missing in\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	for _, test := range []struct {
+		n, total int
+		want     string
+	}{
+		{0, 0, "0.0%"},
+		{1, 3, "33.3%"},
+		{7, 7, "100.0%"},
+	} {
+		if got := analyze.Percent(test.n, test.total); got != test.want {
+			t.Errorf("Percent(%d, %d) = %q, wanted %q", test.n, test.total, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package analyze
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+type htmlNode struct {
+	Name     string
+	Title    string
+	Bytes    int
+	Row      bool
+	Hue      int
+	Light    int
+	Children []*htmlNode
+}
+
+type htmlPage struct {
+	Title string
+	Root  *htmlNode
+}
+
+// WriteHTML writes a standalone HTML page with a treemap of the result,
+// where the area of every directory and source is proportional to its bytes.
+func (r *Result) WriteHTML(w io.Writer, title string) error {
+	root := newHTMLNode(r.Tree, r.TotalBytes, 0, 0)
+	root.Name = title
+	return htmlTemplate.Execute(w, &htmlPage{
+		Title: title,
+		Root:  root,
+	})
+}
+
+// newHTMLNode lays out the children of the node in rows and columns
+// by turns. The top-level nodes get distinct hues, which their
+// descendants keep.
+func newHTMLNode(n *Node, total, depth, hue int) *htmlNode {
+	hn := &htmlNode{
+		Name:  n.Name,
+		Title: fmt.Sprintf("%s: %d bytes (%s)", n.Name, n.Bytes, Percent(n.Bytes, total)),
+		Bytes: n.Bytes,
+		Row:   depth%2 == 0,
+		Hue:   hue,
+		Light: 90 - 8*depth,
+	}
+	if hn.Light < 50 {
+		hn.Light = 50
+	}
+	for i, c := range n.Children {
+		if c.Bytes == 0 {
+			continue
+		}
+		childHue := hue
+		if depth == 0 {
+			childHue = i * 137 % 360
+		}
+		hn.Children = append(hn.Children, newHTMLNode(c, total, depth+1, childHue))
+	}
+	return hn
+}
+
+// Percent formats n as a percentage of total with one decimal.
+func Percent(n, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+var htmlTemplate = template.Must(template.New("treemap").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font-family: sans-serif; }
+.treemap { display: flex; width: 100vw; height: 100vh; }
+.n { display: flex; flex-direction: column; flex-basis: 0; min-width: 0; min-height: 0;
+  overflow: hidden; box-sizing: border-box; border: 1px solid rgba(0, 0, 0, .25); }
+.kids { display: flex; flex: 1; min-width: 0; min-height: 0; }
+.row { flex-direction: row; }
+.col { flex-direction: column; }
+.label { font-size: 11px; padding: 0 2px; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+</style>
+</head>
+<body>
+<div class="treemap">{{template "node" .Root}}</div>
+</body>
+</html>
+{{define "node"}}<div class="n" style="flex-grow: {{.Bytes}}; background: hsl({{.Hue}}, 60%, {{.Light}}%)" title="{{.Title}}"><span class="label">{{.Name}}</span>{{if .Children}}<div class="kids {{if .Row}}row{{else}}col{{end}}">{{range .Children}}{{template "node" .}}{{end}}</div>{{end}}</div>{{end}}`))
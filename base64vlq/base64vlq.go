@@ -0,0 +1,236 @@
+// Package base64vlq implements the Base64 VLQ encoding of the mappings
+// of source maps. Values are signed unless the Unsigned variants are used,
+// which encode them without a sign bit as in the scopes field.
+package base64vlq
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+const encodeStd = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+const (
+	vlqBaseShift       = 5
+	vlqBase            = 1 << vlqBaseShift
+	vlqBaseMask        = vlqBase - 1
+	vlqSignBit         = 1
+	vlqContinuationBit = vlqBase
+)
+
+// invalid marks the characters that are not Base64 digits in decodeMap.
+const invalid = 0xff
+
+var (
+	// ErrInvalidChar is returned for characters that are not Base64 digits.
+	ErrInvalidChar = errors.New("base64vlq: invalid character")
+	// ErrOverflow is returned for values that do not fit in 32 bits.
+	ErrOverflow = errors.New("base64vlq: value overflows 32 bits")
+)
+
+// maxBits is the number of bits that are decoded, which hold 32 bits and
+// the sign bit.
+const maxBits = 35
+
+var decodeMap [256]byte
+
+func init() {
+	for i := range decodeMap {
+		decodeMap[i] = invalid
+	}
+	for i := 0; i < len(encodeStd); i++ {
+		decodeMap[encodeStd[i]] = byte(i)
+	}
+}
+
+// toVLQSigned uses 64 bits to hold the magnitude of math.MinInt32.
+func toVLQSigned(n int32) uint64 {
+	if n < 0 {
+		return uint64(-int64(n))<<1 + 1
+	}
+	return uint64(n) << 1
+}
+
+func fromVLQSigned(n uint64) (int32, error) {
+	isNeg := n&vlqSignBit != 0
+	m := int64(n >> 1)
+	if isNeg {
+		if m > -math.MinInt32 {
+			return 0, ErrOverflow
+		}
+		return int32(-m), nil
+	}
+	if m > math.MaxInt32 {
+		return 0, ErrOverflow
+	}
+	return int32(m), nil
+}
+
+func fromVLQUnsigned(n uint64) (uint32, error) {
+	if n > math.MaxUint32 {
+		return 0, ErrOverflow
+	}
+	return uint32(n), nil
+}
+
+// addDigit adds the bits of the digit at shift to v. Digits past maxBits
+// saturate v, so that the value is consumed but overflows.
+func addDigit(v uint64, shift uint, c byte) uint64 {
+	digit := uint64(c & vlqBaseMask)
+	if digit == 0 {
+		return v
+	}
+	if shift >= maxBits {
+		return math.MaxUint64
+	}
+	return v | digit<<shift
+}
+
+// AppendEncode appends the encoding of n to dst and returns the result.
+func AppendEncode(dst []byte, n int32) []byte {
+	return appendEncode(dst, toVLQSigned(n))
+}
+
+// AppendEncodeUnsigned is like AppendEncode, but encodes n without a sign bit.
+func AppendEncodeUnsigned(dst []byte, n uint32) []byte {
+	return appendEncode(dst, uint64(n))
+}
+
+func appendEncode(dst []byte, v uint64) []byte {
+	for {
+		digit := v & vlqBaseMask
+		v >>= vlqBaseShift
+		if v == 0 {
+			return append(dst, encodeStd[digit])
+		}
+		dst = append(dst, encodeStd[digit|vlqContinuationBit])
+	}
+}
+
+// DecodeString decodes the value that starts at s[i] and returns it
+// with the index of the byte that follows it. It returns io.EOF if i
+// is at the end of s and io.ErrUnexpectedEOF if the value is truncated.
+// For an invalid character, next is its index. Values that do not fit
+// in an int32 return ErrOverflow, with next after the value.
+func DecodeString(s string, i int) (n int32, next int, err error) {
+	v, next, err := decodeString(s, i)
+	if err != nil {
+		return 0, next, err
+	}
+	n, err = fromVLQSigned(v)
+	return n, next, err
+}
+
+// DecodeUnsignedString is like DecodeString for values without a sign bit,
+// which overflow if they do not fit in a uint32.
+func DecodeUnsignedString(s string, i int) (n uint32, next int, err error) {
+	v, next, err := decodeString(s, i)
+	if err != nil {
+		return 0, next, err
+	}
+	n, err = fromVLQUnsigned(v)
+	return n, next, err
+}
+
+func decodeString(s string, i int) (uint64, int, error) {
+	if i >= len(s) {
+		return 0, i, io.EOF
+	}
+	var v uint64
+	shift := uint(0)
+	for ; i < len(s); i++ {
+		c := decodeMap[s[i]]
+		if c == invalid {
+			return 0, i, ErrInvalidChar
+		}
+		v = addDigit(v, shift, c)
+		shift += vlqBaseShift
+		if c&vlqContinuationBit == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, i, io.ErrUnexpectedEOF
+}
+
+// Encoder writes encoded values to a byte writer.
+type Encoder struct {
+	w io.ByteWriter
+}
+
+func NewEncoder(w io.ByteWriter) *Encoder {
+	return &Encoder{
+		w: w,
+	}
+}
+
+func (enc Encoder) Encode(n int32) error {
+	return enc.encode(toVLQSigned(n))
+}
+
+// EncodeUnsigned encodes n without a sign bit.
+func (enc Encoder) EncodeUnsigned(n uint32) error {
+	return enc.encode(uint64(n))
+}
+
+func (enc Encoder) encode(v uint64) error {
+	var buf [16]byte
+	for _, c := range appendEncode(buf[:0], v) {
+		if err := enc.w.WriteByte(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decoder reads encoded values from a byte reader. DecodeString is
+// faster for values that are held in a string.
+type Decoder struct {
+	r io.ByteReader
+}
+
+func NewDecoder(r io.ByteReader) Decoder {
+	return Decoder{
+		r: r,
+	}
+}
+
+func (dec Decoder) Decode() (n int32, err error) {
+	v, err := dec.decode()
+	if err != nil {
+		return 0, err
+	}
+	return fromVLQSigned(v)
+}
+
+// DecodeUnsigned decodes a value that has no sign bit.
+func (dec Decoder) DecodeUnsigned() (n uint32, err error) {
+	v, err := dec.decode()
+	if err != nil {
+		return 0, err
+	}
+	return fromVLQUnsigned(v)
+}
+
+func (dec Decoder) decode() (uint64, error) {
+	var v uint64
+	shift := uint(0)
+	for continuation := true; continuation; {
+		c, err := dec.r.ReadByte()
+		if err == io.EOF && shift > 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		c = decodeMap[c]
+		if c == invalid {
+			return 0, ErrInvalidChar
+		}
+		continuation = c&vlqContinuationBit != 0
+		v = addDigit(v, shift, c)
+		shift += vlqBaseShift
+	}
+	return v, nil
+}
@@ -0,0 +1,257 @@
+package base64vlq_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap/base64vlq"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	buf := new(bytes.Buffer)
+	enc := base64vlq.NewEncoder(buf)
+	dec := base64vlq.NewDecoder(buf)
+
+	for n := int32(-1000); n < 1000; n++ {
+		if err := enc.Encode(n); err != nil {
+			panic(err)
+		}
+	}
+
+	for n := int32(-1000); n < 1000; n++ {
+		nn, err := dec.Decode()
+		if err != nil {
+			panic(err)
+		}
+
+		if nn != n {
+			t.Errorf("%d != %d", nn, n)
+		}
+	}
+}
+
+func TestEncodeDecodeLimits(t *testing.T) {
+	for _, n := range []int32{math.MinInt32, math.MinInt32 + 1, math.MaxInt32} {
+		buf := new(bytes.Buffer)
+		if err := base64vlq.NewEncoder(buf).Encode(n); err != nil {
+			t.Fatal(err)
+		}
+		nn, err := base64vlq.NewDecoder(buf).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nn != n {
+			t.Errorf("%d != %d", nn, n)
+		}
+	}
+}
+
+func TestEncodeDecodeUnsigned(t *testing.T) {
+	buf := new(bytes.Buffer)
+	enc := base64vlq.NewEncoder(buf)
+	dec := base64vlq.NewDecoder(buf)
+
+	values := []uint32{0, 1, 15, 16, 31, 32, 1000, math.MaxInt32, math.MaxUint32}
+	for _, n := range values {
+		if err := enc.EncodeUnsigned(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := buf.String()[:6]; got != "ABPQfg" {
+		t.Fatalf("got %q, wanted %q", got, "ABPQfg")
+	}
+	for _, n := range values {
+		nn, err := dec.DecodeUnsigned()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nn != n {
+			t.Errorf("%d != %d", nn, n)
+		}
+	}
+}
+
+func TestAppendEncodeDecodeString(t *testing.T) {
+	var b []byte
+	for n := int32(-1000); n < 1000; n++ {
+		b = base64vlq.AppendEncode(b, n)
+	}
+	b = base64vlq.AppendEncode(b, math.MinInt32)
+	b = base64vlq.AppendEncodeUnsigned(b, math.MaxUint32)
+
+	s := string(b)
+	i := 0
+	for n := int32(-1000); n < 1000; n++ {
+		nn, next, err := base64vlq.DecodeString(s, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nn != n {
+			t.Errorf("%d != %d", nn, n)
+		}
+		i = next
+	}
+	nn, i, err := base64vlq.DecodeString(s, i)
+	if err != nil || nn != math.MinInt32 {
+		t.Fatalf("got %d, %v, wanted %d", nn, err, int32(math.MinInt32))
+	}
+	un, i, err := base64vlq.DecodeUnsignedString(s, i)
+	if err != nil || un != math.MaxUint32 {
+		t.Fatalf("got %d, %v, wanted %d", un, err, uint32(math.MaxUint32))
+	}
+	if _, _, err := base64vlq.DecodeString(s, i); err != io.EOF {
+		t.Fatalf("got %v, wanted io.EOF", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		s    string
+		next int
+		err  error
+	}{
+		{"", 0, io.EOF},
+		{"g", 1, io.ErrUnexpectedEOF},
+		{"A!", 1, base64vlq.ErrInvalidChar},
+		{"g=", 1, base64vlq.ErrInvalidChar},
+		{"A,", 1, base64vlq.ErrInvalidChar},
+		{"+/////DggggggE", 14, base64vlq.ErrOverflow},
+		{"hgggggEjgggggE", 14, base64vlq.ErrOverflow},
+		{"hgggggggggggggggggE", 19, base64vlq.ErrOverflow},
+	}
+	for _, test := range tests {
+		i := 0
+		var err error
+		for err == nil {
+			_, i, err = base64vlq.DecodeString(test.s, i)
+		}
+		if err != test.err || i != test.next {
+			t.Errorf("%q: got %v at %d, wanted %v at %d", test.s, err, i, test.err, test.next)
+		}
+
+		dec := base64vlq.NewDecoder(bytes.NewReader([]byte(test.s)))
+		err = nil
+		for err == nil {
+			_, err = dec.Decode()
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: got %v, wanted %v", test.s, err, test.err)
+		}
+	}
+}
+
+func TestDecodeUnsignedOverflow(t *testing.T) {
+	for _, s := range []string{"ggggggE", "gggggggggggggB"} {
+		_, next, err := base64vlq.DecodeUnsignedString(s, 0)
+		if err != base64vlq.ErrOverflow || next != len(s) {
+			t.Errorf("%q: got %v at %d, wanted %v at %d", s, err, next, base64vlq.ErrOverflow, len(s))
+		}
+		_, err = base64vlq.NewDecoder(bytes.NewReader([]byte(s))).DecodeUnsigned()
+		if err != base64vlq.ErrOverflow {
+			t.Errorf("%q: got %v, wanted %v", s, err, base64vlq.ErrOverflow)
+		}
+	}
+	if n, _, err := base64vlq.DecodeUnsignedString("//////D", 0); err != nil || n != math.MaxUint32 {
+		t.Errorf("got %d, %v, wanted %d", n, err, uint32(math.MaxUint32))
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, s := range []string{"A", "AAAA", "gB", "+/////D", "hgggggggggggggggggE", "!?"} {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var values []int32
+		dec := base64vlq.NewDecoder(bytes.NewReader(b))
+		for {
+			n, err := dec.Decode()
+			if err != nil {
+				break
+			}
+			values = append(values, n)
+		}
+
+		buf := new(bytes.Buffer)
+		enc := base64vlq.NewEncoder(buf)
+		for _, n := range values {
+			if err := enc.Encode(n); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s := string(b)
+		i := 0
+		for _, n := range values {
+			nn, next, err := base64vlq.DecodeString(s, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if nn != n {
+				t.Fatalf("DecodeString: %d != %d", nn, n)
+			}
+			i = next
+		}
+
+		dec = base64vlq.NewDecoder(buf)
+		for _, n := range values {
+			nn, err := dec.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if nn != n {
+				t.Fatalf("%d != %d", nn, n)
+			}
+		}
+	})
+}
+
+func BenchmarkEncode(b *testing.B) {
+	buf := new(bytes.Buffer)
+	enc := base64vlq.NewEncoder(buf)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := enc.Encode(1000); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkEncodeDecode(b *testing.B) {
+	buf := new(bytes.Buffer)
+	enc := base64vlq.NewEncoder(buf)
+	dec := base64vlq.NewDecoder(buf)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := enc.Encode(1000); err != nil {
+			panic(err)
+		}
+
+		_, err := dec.Decode()
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkAppendEncode(b *testing.B) {
+	buf := make([]byte, 0, 16)
+	for i := 0; i < b.N; i++ {
+		buf = base64vlq.AppendEncode(buf[:0], 1000)
+	}
+}
+
+func BenchmarkDecodeString(b *testing.B) {
+	s := string(base64vlq.AppendEncode(nil, 1000))
+	for i := 0; i < b.N; i++ {
+		if _, _, err := base64vlq.DecodeString(s, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
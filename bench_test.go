@@ -7,8 +7,11 @@ import (
 )
 
 func BenchmarkParse(b *testing.B) {
+	data := readFile(b, webpackSourceMapFile)
+	b.ResetTimer()
+
 	for i := 0; i < b.N; i++ {
-		_, err := sourcemap.Parse(jqSourceMapURL, jqSourceMapBytes)
+		_, err := sourcemap.Parse(webpackSourceMapURL, data)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -16,7 +19,7 @@ func BenchmarkParse(b *testing.B) {
 }
 
 func BenchmarkSource(b *testing.B) {
-	smap, err := sourcemap.Parse(jqSourceMapURL, jqSourceMapBytes)
+	smap, err := sourcemap.Parse(webpackSourceMapURL, readFile(b, webpackSourceMapFile))
 	if err != nil {
 		b.Fatal(err)
 	}
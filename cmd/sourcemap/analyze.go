@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap/analyze"
+)
+
+func analyzeFlags(e *env, fs *flag.FlagSet) {
+	fs.StringVar(&e.generated, "generated", "",
+		"the generated `file`, which is the map without .map by default")
+	fs.BoolVar(&e.html, "html", false, "write an HTML treemap")
+}
+
+func analyzeCmd(e *env, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	smap, err := readMap(args[0])
+	if err != nil {
+		return err
+	}
+
+	genFile := e.generated
+	if genFile == "" {
+		genFile = strings.TrimSuffix(args[0], ".map")
+	}
+	gen, err := os.ReadFile(genFile)
+	if err != nil {
+		return err
+	}
+
+	r := analyze.Analyze(smap, gen)
+	switch {
+	case e.json:
+		return e.writeJSON(r)
+	case e.html:
+		return r.WriteHTML(e.stdout, filepath.Base(genFile))
+	}
+
+	fmt.Fprintf(e.stdout, "total:    %9d bytes\n", r.TotalBytes)
+	fmt.Fprintf(e.stdout, "unmapped: %9d bytes %6s, including %d bytes of line terminators\n",
+		r.UnmappedBytes, analyze.Percent(r.UnmappedBytes, r.TotalBytes), r.EOLBytes)
+	for _, group := range []struct {
+		name    string
+		entries []analyze.Entry
+	}{
+		{"packages", r.Packages},
+		{"directories", r.Directories},
+		{"files", r.Files},
+	} {
+		if len(group.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(e.stdout, "\n%s:\n", group.name)
+		for _, entry := range group.entries {
+			fmt.Fprintf(e.stdout, "%9d %6s  %s\n",
+				entry.Bytes, analyze.Percent(entry.Bytes, r.TotalBytes), entry.Name)
+		}
+	}
+	return nil
+}
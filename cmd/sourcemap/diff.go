@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func diffCmd(e *env, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	a, err := readMap(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := readMap(args[1])
+	if err != nil {
+		return err
+	}
+
+	d := sourcemap.Diff(a, b)
+	if e.json {
+		if err := e.writeJSON(d); err != nil {
+			return err
+		}
+	} else {
+		writeDiff(e, d)
+	}
+	if !d.Empty() {
+		return errors.New("the maps differ")
+	}
+	return nil
+}
+
+func writeDiff(e *env, d *sourcemap.Difference) {
+	for _, s := range d.RemovedSources {
+		fmt.Fprintf(e.stdout, "- source %s\n", s)
+	}
+	for _, s := range d.AddedSources {
+		fmt.Fprintf(e.stdout, "+ source %s\n", s)
+	}
+	for _, s := range d.ChangedContent {
+		fmt.Fprintf(e.stdout, "~ content %s\n", s)
+	}
+	for _, c := range d.Mappings {
+		fmt.Fprintf(e.stdout, "~ %d:%d %s -> %s\n",
+			c.GenLine, c.GenColumn, mappingTarget(c.A), mappingTarget(c.B))
+	}
+	for _, c := range d.Names {
+		fmt.Fprintf(e.stdout, "~ %d:%d name %q -> %q\n",
+			c.GenLine, c.GenColumn, c.A.Name, c.B.Name)
+	}
+}
+
+func mappingTarget(m *sourcemap.Mapping) string {
+	switch {
+	case m == nil:
+		return "(none)"
+	case m.Source == "":
+		return "(unmapped)"
+	}
+	return fmt.Sprintf("%s:%d:%d", m.Source, m.Line, m.Column)
+}
@@ -0,0 +1,379 @@
+// Command sourcemap looks up and inspects source maps on the local filesystem.
+//
+// Usage:
+//
+//	sourcemap <command> [-json] <arguments>
+//
+// Lines are 1-based and columns are 0-based as in source maps.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+const usage = `usage: sourcemap <command> [-json] <arguments>
+
+commands:
+  lookup MAP LINE:COL           original position of a generated position
+  reverse MAP SOURCE:LINE:COL   generated position of an original position
+  info MAP                      summary of the map
+  sources MAP                   sources of the map
+  cat-source MAP SOURCE         content of a source
+  symbolicate MAP...            map the stack trace read from stdin, naming
+                                the functions if the generated files exist
+  validate [-generated FILE] [-format text|json|sarif] MAP
+                                check the map and exit with 1 on errors
+  visualize [-generated FILE] [-html] MAP
+                                show the segments of the generated code and
+                                the original spans they map to
+  analyze [-generated FILE] [-html] MAP
+                                bytes of the generated code by source,
+                                directory and node_modules package
+  diff MAP MAP                  compare the decoded maps and exit with 1
+                                if they differ
+
+Lines are 1-based and columns are 0-based.
+`
+
+// errUsage is returned by commands that got wrong arguments.
+var errUsage = errors.New("wrong arguments")
+
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+
+	// Flags.
+	json      bool
+	generated string
+	format    string
+	html      bool
+}
+
+type command struct {
+	run func(e *env, args []string) error
+	// flags registers the flags of the command other than -json.
+	flags func(e *env, fs *flag.FlagSet)
+}
+
+var commands = map[string]command{
+	"lookup":      {run: lookupCmd},
+	"reverse":     {run: reverseCmd},
+	"info":        {run: infoCmd},
+	"sources":     {run: sourcesCmd},
+	"cat-source":  {run: catSourceCmd},
+	"symbolicate": {run: symbolicateCmd},
+	"validate":    {run: validateCmd, flags: validateFlags},
+	"visualize":   {run: visualizeCmd, flags: visualizeFlags},
+	"analyze":     {run: analyzeCmd, flags: analyzeFlags},
+	"diff":        {run: diffCmd},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command and returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "sourcemap: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	e := &env{
+		stdin:  stdin,
+		stdout: stdout,
+	}
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&e.json, "json", false, "write JSON")
+	if cmd.flags != nil {
+		cmd.flags(e, flags)
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	err := cmd.run(e, flags.Args())
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "sourcemap %s: %s\n\n%s", args[0], err, usage)
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "sourcemap %s: %s\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// writeJSON writes v as indented JSON.
+func (e *env) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// readMap parses the map file. Sources are resolved against the path
+// of the map, and the content of the sources that are not embedded
+// in the map is read from the filesystem. The maps are files that are
+// named by the user, so their sources may be anywhere.
+func readMap(name string) (*sourcemap.Consumer, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	loader := sourcemap.DirLoader{Trusted: true}
+	return sourcemap.Parse(name, b, sourcemap.WithContentLoader(loader))
+}
+
+// parsePos parses a "LINE:COL" position.
+func parsePos(s string) (line, col int, err error) {
+	i := strings.LastIndexByte(s, ':')
+	if i == -1 {
+		return 0, 0, fmt.Errorf("%w: got position %q, wanted LINE:COL", errUsage, s)
+	}
+	line, err = strconv.Atoi(s[:i])
+	if err == nil {
+		col, err = strconv.Atoi(s[i+1:])
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: got position %q, wanted LINE:COL", errUsage, s)
+	}
+	return line, col, nil
+}
+
+type position struct {
+	Source string `json:"source,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func (p *position) String() string {
+	s := fmt.Sprintf("%d:%d", p.Line, p.Column)
+	if p.Source != "" {
+		s = p.Source + ":" + s
+	}
+	if p.Name != "" {
+		s += " " + p.Name
+	}
+	return s
+}
+
+func (e *env) writePosition(p *position) error {
+	if e.json {
+		return e.writeJSON(p)
+	}
+	_, err := fmt.Fprintln(e.stdout, p)
+	return err
+}
+
+func lookupCmd(e *env, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	line, col, err := parsePos(args[1])
+	if err != nil {
+		return err
+	}
+	smap, err := readMap(args[0])
+	if err != nil {
+		return err
+	}
+
+	source, name, line, col, ok := smap.Source(line, col)
+	if !ok || source == "" {
+		return fmt.Errorf("no mapping for %s", args[1])
+	}
+	return e.writePosition(&position{
+		Source: source,
+		Name:   name,
+		Line:   line,
+		Column: col,
+	})
+}
+
+func reverseCmd(e *env, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	i := strings.LastIndexByte(args[1], ':')
+	if i != -1 {
+		i = strings.LastIndexByte(args[1][:i], ':')
+	}
+	if i == -1 {
+		return fmt.Errorf("%w: got %q, wanted SOURCE:LINE:COL", errUsage, args[1])
+	}
+	source := args[1][:i]
+	line, col, err := parsePos(args[1][i+1:])
+	if err != nil {
+		return err
+	}
+	smap, err := readMap(args[0])
+	if err != nil {
+		return err
+	}
+
+	line, col, ok := smap.Generated(source, line, col)
+	if !ok {
+		return fmt.Errorf("no mapping for %s", args[1])
+	}
+	return e.writePosition(&position{
+		Line:   line,
+		Column: col,
+	})
+}
+
+type info struct {
+	Version            int    `json:"version"`
+	File               string `json:"file,omitempty"`
+	DebugID            string `json:"debugId,omitempty"`
+	Sources            int    `json:"sources"`
+	SourcesWithContent int    `json:"sourcesWithContent"`
+	Mappings           int    `json:"mappings"`
+	Sections           int    `json:"sections"`
+}
+
+func infoCmd(e *env, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	b, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	smap, err := sourcemap.Parse(args[0], b)
+	if err != nil {
+		return err
+	}
+
+	// The Consumer accepts a missing version, so report the one in the file.
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return err
+	}
+
+	v := info{
+		Version:  header.Version,
+		File:     smap.File(),
+		DebugID:  smap.DebugID(),
+		Sections: len(smap.Sections()),
+	}
+	for _, si := range smap.Sources() {
+		if si.Null {
+			continue
+		}
+		v.Sources++
+		if si.HasContent {
+			v.SourcesWithContent++
+		}
+	}
+	smap.EachMapping(func(sourcemap.Mapping) bool {
+		v.Mappings++
+		return true
+	})
+
+	if e.json {
+		return e.writeJSON(&v)
+	}
+	fmt.Fprintf(e.stdout, "version:  %d\n", v.Version)
+	if v.File != "" {
+		fmt.Fprintf(e.stdout, "file:     %s\n", v.File)
+	}
+	if v.DebugID != "" {
+		fmt.Fprintf(e.stdout, "debugId:  %s\n", v.DebugID)
+	}
+	fmt.Fprintf(e.stdout, "sources:  %d (%d with content)\n", v.Sources, v.SourcesWithContent)
+	fmt.Fprintf(e.stdout, "mappings: %d\n", v.Mappings)
+	_, err = fmt.Fprintf(e.stdout, "sections: %d\n", v.Sections)
+	return err
+}
+
+type source struct {
+	URL        string `json:"url"`
+	HasContent bool   `json:"hasContent"`
+	Ignored    bool   `json:"ignored,omitempty"`
+}
+
+func sourcesCmd(e *env, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	smap, err := readMap(args[0])
+	if err != nil {
+		return err
+	}
+
+	sources := []source{}
+	for _, si := range smap.Sources() {
+		if si.Null {
+			continue
+		}
+		sources = append(sources, source{
+			URL:        si.URL,
+			HasContent: si.HasContent,
+			Ignored:    si.Ignored,
+		})
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].URL < sources[j].URL
+	})
+
+	if e.json {
+		return e.writeJSON(sources)
+	}
+	for _, s := range sources {
+		var flags []string
+		if !s.HasContent {
+			flags = append(flags, "no content")
+		}
+		if s.Ignored {
+			flags = append(flags, "ignored")
+		}
+		if len(flags) > 0 {
+			fmt.Fprintf(e.stdout, "%s (%s)\n", s.URL, strings.Join(flags, ", "))
+		} else {
+			fmt.Fprintln(e.stdout, s.URL)
+		}
+	}
+	return nil
+}
+
+func catSourceCmd(e *env, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	smap, err := readMap(args[0])
+	if err != nil {
+		return err
+	}
+
+	content, ok := smap.LookupSourceContent(args[1])
+	if !ok {
+		return fmt.Errorf("no content for %q", args[1])
+	}
+	if e.json {
+		return e.writeJSON(struct {
+			Source  string `json:"source"`
+			Content string `json:"content"`
+		}{args[1], content})
+	}
+	_, err = io.WriteString(e.stdout, content)
+	return err
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	tsMap    = "../../testdata/corpus/typescript/context.js.map"
+	tsSource = "../../testdata/corpus/ts/context.ts"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+	}{
+		{[]string{"lookup", tsMap, "8:37"}, "", 0, tsSource + ":18:39\n"},
+		{[]string{"lookup", "-json", tsMap, "8:37"}, "", 0, `"line": 18`},
+		{[]string{"lookup", tsMap, "1:0"}, "", 1, ""},
+		{[]string{"lookup", tsMap, "8"}, "", 2, ""},
+		{[]string{"reverse", tsMap, tsSource + ":18:39"}, "", 0, "8:37\n"},
+		{[]string{"reverse", tsMap, tsSource + ":18:40"}, "", 0, "8:37\n"},
+		{[]string{"reverse", tsMap, "other.ts:18:39"}, "", 1, ""},
+		{[]string{"info", tsMap}, "", 0, "sources:  1 (1 with content)\n"},
+		{[]string{"info", "-json", tsMap}, "", 0, `"file": "context.js"`},
+		{[]string{"sources", tsMap}, "", 0, tsSource + "\n"},
+		{[]string{"cat-source", tsMap, tsSource}, "", 0, "class QuickJSContext"},
+		{[]string{"cat-source", tsMap, "other.ts"}, "", 1, ""},
+		{
+			[]string{"symbolicate", tsMap},
+			"Error: boom\n    at f (http://localhost/context.js:8:38)\n    at http://localhost/context.js:8:38\n",
+			0,
+			"Error: boom\n    at f (" + tsSource + ":18:40)\n    at " + tsSource + ":18:40\n",
+		},
+		{
+			[]string{"symbolicate", tsMap},
+			"f@http://localhost/context.js:8:38\n@http://localhost/other.js:1:1\n",
+			0,
+			"f@" + tsSource + ":18:40\n@http://localhost/other.js:1:1\n",
+		},
+		{
+			// The function name is resolved with the generated file.
+			[]string{"symbolicate", tsMap},
+			"    at e.manage (http://localhost/context.js:41:9)\n",
+			0,
+			"    at manage (" + tsSource + ":84:5)\n",
+		},
+		{[]string{"visualize", "-html", tsMap}, "", 0, "<h2>context.js</h2>"},
+		{[]string{"visualize", tsMap}, "", 0, "== " + tsSource + " =="},
+		{[]string{"analyze", tsMap}, "", 0, "  " + tsSource + "\n"},
+		{[]string{"analyze", "-json", tsMap}, "", 0, `"totalBytes": `},
+		{[]string{"diff", tsMap, tsMap}, "", 0, ""},
+		{[]string{"diff", tsMap, "../../testdata/corpus/index-map/bundle.js.map"}, "", 1, "+ source webpack:///lib/util.js\n"},
+		{[]string{"unknown"}, "", 2, ""},
+		{nil, "", 2, ""},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+		if code != test.code {
+			t.Errorf("%q: got exit code %d, wanted %d: %s", test.args, code, test.code, stderr.String())
+			continue
+		}
+		if !strings.Contains(stdout.String(), test.stdout) {
+			t.Errorf("%q: got %q, wanted %q", test.args, stdout.String(), test.stdout)
+		}
+	}
+}
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		s    string
+		ok   bool
+		fn   string
+		file string
+	}{
+		{"    at foo (https://example.com/app.js:1:2)", true, "foo", "https://example.com/app.js"},
+		{"    at new Foo (app.js:1:2)", true, "new Foo", "app.js"},
+		{"    at app.js:1:2", true, "", "app.js"},
+		{"    at app.js:1:2)", false, "", ""},
+		{"foo@https://example.com/app.js:1:2", true, "foo", "https://example.com/app.js"},
+		{"@app.js:1:2", true, "", "app.js"},
+		{"Error: boom", false, "", ""},
+	}
+	for _, test := range tests {
+		f, ok := parseFrame(test.s)
+		if ok != test.ok {
+			t.Errorf("%q: got %v, wanted %v", test.s, ok, test.ok)
+			continue
+		}
+		if ok && (f.Function != test.fn || f.File != test.file || f.Line != 1 || f.Column != 2) {
+			t.Errorf("%q: got %+v", test.s, f)
+		}
+	}
+}
+
+func TestParseWasmFrame(t *testing.T) {
+	for _, s := range []string{
+		"    at fib (wasm://wasm/4f3c2b1a:wasm-function[12]:0x3a4f)",
+		"    at https://example.com/app.wasm:wasm-function[0]:0x1f",
+		"fib@https://example.com/app.wasm:wasm-function[12]:0x3a4f",
+	} {
+		f, ok := parseFrame(s)
+		if !ok || !f.Wasm {
+			t.Errorf("%q: got %+v, %v", s, f, ok)
+			continue
+		}
+		if got := f.String(); got != s {
+			t.Errorf("got %q, wanted %q", got, s)
+		}
+	}
+	if f, _ := parseFrame("    at fib (wasm://wasm/4f3c2b1a:wasm-function[12]:0x3a4f)"); f.WasmFunction != 12 || f.Offset != 0x3a4f {
+		t.Errorf("got %+v", f)
+	}
+}
+
+func TestSymbolicateWasm(t *testing.T) {
+	mapFile := filepath.Join(t.TempDir(), "app.wasm.map")
+	err := os.WriteFile(mapFile, []byte(`{
+  "version": 3,
+  "sources": ["lib.rs"],
+  "names": [],
+  "mappings": "gCAAA,0BACC"
+}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("fib@https://example.com/app.wasm:wasm-function[1]:0x3a\n")
+	if code := run([]string{"symbolicate", mapFile}, stdin, &stdout, &stderr); code != 0 {
+		t.Fatalf("got exit code %d: %s", code, stderr.String())
+	}
+	if wanted := "/lib.rs:2:2\n"; !strings.HasPrefix(stdout.String(), "fib@") ||
+		!strings.HasSuffix(stdout.String(), wanted) {
+		t.Errorf("got %q, wanted a frame in lib.rs", stdout.String())
+	}
+}
+
+func TestSymbolicateRAMBundle(t *testing.T) {
+	mapFile := filepath.Join(t.TempDir(), "main.jsbundle.map")
+	err := os.WriteFile(mapFile, []byte(`{
+  "version": 3,
+  "sections": [
+    {"offset": {"line": 0, "column": 0}, "map": {"version": 3, "sources": ["index.js"], "names": [], "mappings": "AAAA"}},
+    {"offset": {"line": 3, "column": 0}, "map": {"version": 3, "sources": ["app.js"], "names": [], "mappings": ";AAAA,EAAE"}}
+  ],
+  "x_facebook_offsets": [0, 3]
+}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("    at f (1.js:2:3)\n")
+	if code := run([]string{"symbolicate", mapFile}, stdin, &stdout, &stderr); code != 0 {
+		t.Fatalf("got exit code %d: %s", code, stderr.String())
+	}
+	// Sources are relative to the map.
+	if wanted := "/app.js:1:3)\n"; !strings.HasSuffix(stdout.String(), wanted) {
+		t.Errorf("got %q, wanted a frame in app.js", stdout.String())
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+var (
+	// v8Frame matches "    at fn (file:1:2)" and "    at file:1:2".
+	v8Frame = regexp.MustCompile(`^(\s*at )(?:(.*?) \()?(\S+?):(\d+):(\d+)(\)?)$`)
+	// firefoxFrame matches "fn@file:1:2", which is also used by Safari.
+	firefoxFrame = regexp.MustCompile(`^(\s*)(.*?)@(\S+?):(\d+):(\d+)$`)
+	// v8WasmFrame and firefoxWasmFrame match the frames of WebAssembly
+	// functions, whose position is "file:wasm-function[1]:0x2".
+	v8WasmFrame      = regexp.MustCompile(`^(\s*at )(?:(.*?) \()?(\S+?):wasm-function\[(\d+)\]:0x([0-9a-fA-F]+)(\)?)$`)
+	firefoxWasmFrame = regexp.MustCompile(`^(\s*)(.*?)@(\S+?):wasm-function\[(\d+)\]:0x([0-9a-fA-F]+)$`)
+	// ramModule matches the files of the modules of RAM bundles.
+	ramModule = regexp.MustCompile(`^(\d+)\.js$`)
+)
+
+// frame is a stack frame. Lines and columns are 1-based as in stack
+// traces, which also applies to the original position.
+type frame struct {
+	Function string    `json:"function,omitempty"`
+	File     string    `json:"file"`
+	Line     int       `json:"line"`
+	Column   int       `json:"column"`
+	Original *position `json:"original,omitempty"`
+	// WasmFunction and Offset are the function index and the byte offset
+	// in the module of WebAssembly frames, which have no line and column.
+	WasmFunction int  `json:"wasmFunction,omitempty"`
+	Offset       int  `json:"offset,omitempty"`
+	Wasm         bool `json:"wasm,omitempty"`
+
+	indent string
+	v8     bool
+}
+
+// parseFrame parses a V8 or Firefox stack frame.
+func parseFrame(s string) (*frame, bool) {
+	if f, ok := parseWasmFrame(s); ok {
+		return f, true
+	}
+	if m := v8Frame.FindStringSubmatch(s); m != nil {
+		// A frame without a function has no parentheses.
+		if (m[2] != "") != (m[6] != "") {
+			return nil, false
+		}
+		f := &frame{
+			Function: m[2],
+			File:     m[3],
+			indent:   m[1],
+			v8:       true,
+		}
+		f.Line, _ = strconv.Atoi(m[4])
+		f.Column, _ = strconv.Atoi(m[5])
+		return f, true
+	}
+	if m := firefoxFrame.FindStringSubmatch(s); m != nil {
+		f := &frame{
+			Function: m[2],
+			File:     m[3],
+			indent:   m[1],
+		}
+		f.Line, _ = strconv.Atoi(m[4])
+		f.Column, _ = strconv.Atoi(m[5])
+		return f, true
+	}
+	return nil, false
+}
+
+func parseWasmFrame(s string) (*frame, bool) {
+	f := &frame{Wasm: true}
+	var m []string
+	if m = v8WasmFrame.FindStringSubmatch(s); m != nil {
+		if (m[2] != "") != (m[6] != "") {
+			return nil, false
+		}
+		f.v8 = true
+	} else if m = firefoxWasmFrame.FindStringSubmatch(s); m == nil {
+		return nil, false
+	}
+	f.indent, f.Function, f.File = m[1], m[2], m[3]
+	f.WasmFunction, _ = strconv.Atoi(m[4])
+	offset, err := strconv.ParseInt(m[5], 16, 0)
+	if err != nil {
+		return nil, false
+	}
+	f.Offset = int(offset)
+	return f, true
+}
+
+func (f *frame) String() string {
+	loc := fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+	switch {
+	case f.Original != nil:
+		loc = fmt.Sprintf("%s:%d:%d", f.Original.Source, f.Original.Line, f.Original.Column)
+	case f.Wasm:
+		loc = fmt.Sprintf("%s:wasm-function[%d]:0x%x", f.File, f.WasmFunction, f.Offset)
+	}
+	if !f.v8 {
+		return fmt.Sprintf("%s%s@%s", f.indent, f.Function, loc)
+	}
+	if f.Function == "" {
+		return fmt.Sprintf("%s%s", f.indent, loc)
+	}
+	return fmt.Sprintf("%s%s (%s)", f.indent, f.Function, loc)
+}
+
+// moduleID returns the module of a RAM bundle that the file is named after.
+func moduleID(file string) (int, bool) {
+	if i := strings.IndexAny(file, "?#"); i != -1 {
+		file = file[:i]
+	}
+	m := ramModule.FindStringSubmatch(path.Base(file))
+	if m == nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(m[1])
+	return id, err == nil
+}
+
+type symbolicateMap struct {
+	name string
+	smap *sourcemap.Consumer
+	// generated is the generated file, which is empty if it is missing.
+	generated string
+}
+
+// mapFor returns the map of the generated file. The file is matched
+// with the file of the map or with the name of the map without ".map".
+// A single map is used for all files.
+func mapFor(maps []symbolicateMap, file string) *symbolicateMap {
+	if i := strings.IndexAny(file, "?#"); i != -1 {
+		file = file[:i]
+	}
+	base := path.Base(file)
+	for i, m := range maps {
+		if (m.smap.File() != "" && path.Base(m.smap.File()) == base) ||
+			strings.TrimSuffix(path.Base(m.name), ".map") == base {
+			return &maps[i]
+		}
+	}
+	if len(maps) == 1 {
+		return &maps[0]
+	}
+	return nil
+}
+
+func symbolicateCmd(e *env, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	var maps []symbolicateMap
+	for _, name := range args {
+		smap, err := readMap(name)
+		if err != nil {
+			return err
+		}
+		// The generated file is used to find the function names.
+		gen, _ := os.ReadFile(strings.TrimSuffix(name, ".map"))
+		maps = append(maps, symbolicateMap{
+			name:      strings.ReplaceAll(name, "\\", "/"),
+			smap:      smap,
+			generated: string(gen),
+		})
+	}
+
+	type line struct {
+		Text  string `json:"text"`
+		Frame *frame `json:"frame,omitempty"`
+	}
+	lines := []line{}
+
+	sc := bufio.NewScanner(e.stdin)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		text := sc.Text()
+		f, ok := parseFrame(text)
+		if ok {
+			if m := mapFor(maps, f.File); m != nil {
+				generated := m.generated
+				source, name, line, col, ok := m.smap.Source(f.Line, f.Column-1)
+				if id, isModule := moduleID(f.File); isModule && m.smap.IsRAMBundle() {
+					source, name, line, col, ok = m.smap.SourceForModule(id, f.Line, f.Column-1)
+					// The positions are in the module, not in the generated file.
+					generated = ""
+				}
+				if f.Wasm {
+					source, name, line, col, ok = m.smap.SourceForWasmOffset(f.Offset)
+					generated = ""
+				}
+				if ok && source != "" {
+					f.Original = &position{
+						Source: source,
+						Name:   name,
+						Line:   line,
+						Column: col + 1,
+					}
+					// Function maps of Metro take precedence.
+					if fn, ok := m.smap.OriginalFunctionName(source, line, col); ok {
+						f.Function = fn
+					} else if fn, ok := m.smap.FunctionName(generated, f.Line, f.Column-1); ok {
+						f.Function = fn
+					}
+					text = f.String()
+				}
+			}
+		} else {
+			f = nil
+		}
+
+		if e.json {
+			lines = append(lines, line{Text: text, Frame: f})
+			continue
+		}
+		fmt.Fprintln(e.stdout, text)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	if e.json {
+		return e.writeJSON(lines)
+	}
+	return nil
+}
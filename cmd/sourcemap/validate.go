@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap"
+	"github.com/go-sourcemap/sourcemap/internal/linecol"
+)
+
+// maxFindings is the number of findings reported for a rule.
+const maxFindings = 100
+
+const (
+	ruleParse            = "parse"
+	ruleGeneratedPos     = "generated-position"
+	ruleOriginalPos      = "original-position"
+	ruleSourceMappingURL = "source-mapping-url"
+)
+
+var ruleDescriptions = map[string]string{
+	ruleParse:            "The source map can't be parsed or has out of range indexes.",
+	ruleGeneratedPos:     "A mapping points past the end of a line of the generated file.",
+	ruleOriginalPos:      "A mapping points past the end of a line of the embedded source content.",
+	ruleSourceMappingURL: "The sourceMappingURL comment of the generated file doesn't point at the map.",
+}
+
+var sourceMappingURLComment = regexp.MustCompile(
+	`(?m)^[ \t]*(?://|/\*)[#@][ \t]+sourceMappingURL=([^\s'"*]+)`)
+
+type finding struct {
+	Rule    string `json:"rule"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	File    string `json:"file"`
+	// Line is 1-based and Column is 0-based. Both are 0 if unknown.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+type validator struct {
+	mapFile   string
+	findings  []finding
+	numByRule map[string]int
+	errors    int
+}
+
+func (v *validator) report(f finding) {
+	if v.numByRule == nil {
+		v.numByRule = make(map[string]int)
+	}
+	v.numByRule[f.Rule]++
+	if f.Level == "error" {
+		v.errors++
+	}
+	if v.numByRule[f.Rule] <= maxFindings {
+		v.findings = append(v.findings, f)
+	}
+}
+
+func (v *validator) errorf(rule, format string, args ...interface{}) {
+	v.report(finding{
+		Rule:    rule,
+		Level:   "error",
+		Message: fmt.Sprintf(format, args...),
+		File:    v.mapFile,
+	})
+}
+
+// errorAt reports an error at the 1-based line and 0-based column of file.
+func (v *validator) errorAt(rule, file string, line, column int, format string, args ...interface{}) {
+	v.report(finding{
+		Rule:    rule,
+		Level:   "error",
+		Message: fmt.Sprintf(format, args...),
+		File:    file,
+		Line:    line,
+		Column:  column,
+	})
+}
+
+func validateFlags(e *env, fs *flag.FlagSet) {
+	fs.StringVar(&e.generated, "generated", "", "check the map against the generated `file`")
+	fs.StringVar(&e.format, "format", "text", "output `format`: text, json or sarif")
+}
+
+func validateCmd(e *env, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	if e.json {
+		e.format = "json"
+	}
+	switch e.format {
+	case "text", "json", "sarif":
+	default:
+		return fmt.Errorf("%w: unknown format %q", errUsage, e.format)
+	}
+
+	v := &validator{mapFile: args[0]}
+	if err := v.validate(args[0], e.generated); err != nil {
+		return err
+	}
+
+	// Summarize the findings that were not reported.
+	for _, rule := range []string{ruleParse, ruleGeneratedPos, ruleOriginalPos, ruleSourceMappingURL} {
+		if n := v.numByRule[rule]; n > maxFindings {
+			v.report(finding{
+				Rule:    rule,
+				Level:   "note",
+				Message: fmt.Sprintf("%d more findings are omitted", n-maxFindings),
+				File:    v.mapFile,
+			})
+		}
+	}
+
+	var err error
+	switch e.format {
+	case "json":
+		findings := v.findings
+		if findings == nil {
+			findings = []finding{}
+		}
+		err = e.writeJSON(findings)
+	case "sarif":
+		err = e.writeJSON(newSARIF(v.findings))
+	default:
+		for _, f := range v.findings {
+			pos := f.File
+			if f.Line > 0 {
+				pos += fmt.Sprintf(":%d:%d", f.Line, f.Column)
+			}
+			fmt.Fprintf(e.stdout, "%s: %s: %s [%s]\n", pos, f.Level, f.Message, f.Rule)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if v.errors > 0 {
+		return fmt.Errorf("found %d errors", v.errors)
+	}
+	return nil
+}
+
+func (v *validator) validate(mapFile, genFile string) error {
+	b, err := os.ReadFile(mapFile)
+	if err != nil {
+		return err
+	}
+	smap, err := sourcemap.Parse(mapFile, b)
+	if err != nil {
+		v.errorf(ruleParse, "%s", err)
+		return nil
+	}
+
+	var genLines []string
+	if genFile != "" {
+		gen, err := os.ReadFile(genFile)
+		if err != nil {
+			return err
+		}
+		genLines = linecol.Split(string(gen))
+		v.checkSourceMappingURL(string(gen), genFile, b)
+	}
+
+	sourceLines := make(map[string][]string)
+	smap.EachMapping(func(m sourcemap.Mapping) bool {
+		if genLines != nil {
+			if m.GenLine > len(genLines) {
+				v.errorAt(ruleGeneratedPos, genFile, m.GenLine, m.GenColumn,
+					"mapping is past the last line %d", len(genLines))
+			} else if n := linecol.UTF16Len(genLines[m.GenLine-1]); m.GenColumn > n {
+				v.errorAt(ruleGeneratedPos, genFile, m.GenLine, m.GenColumn,
+					"mapping is past the end of the line, which has %d columns", n)
+			}
+		}
+
+		if m.Source == "" {
+			return true
+		}
+		lines, ok := sourceLines[m.Source]
+		if !ok {
+			if content, ok := smap.LookupSourceContent(m.Source); ok {
+				lines = linecol.Split(content)
+			}
+			sourceLines[m.Source] = lines
+		}
+		if lines == nil {
+			return true
+		}
+		if m.Line > len(lines) {
+			v.errorAt(ruleOriginalPos, m.Source, m.Line, m.Column,
+				"mapping at %d:%d of the generated code is past the last line %d",
+				m.GenLine, m.GenColumn, len(lines))
+		} else if n := linecol.UTF16Len(lines[m.Line-1]); m.Column > n {
+			v.errorAt(ruleOriginalPos, m.Source, m.Line, m.Column,
+				"mapping at %d:%d of the generated code is past the end of the line, which has %d columns",
+				m.GenLine, m.GenColumn, n)
+		}
+		return true
+	})
+	return nil
+}
+
+// checkSourceMappingURL checks that the last sourceMappingURL comment
+// of the generated code points at the map.
+func (v *validator) checkSourceMappingURL(gen, genFile string, mapContent []byte) {
+	matches := sourceMappingURLComment.FindAllStringSubmatchIndex(gen, -1)
+	if matches == nil {
+		v.report(finding{
+			Rule:    ruleSourceMappingURL,
+			Level:   "warning",
+			Message: "no sourceMappingURL comment",
+			File:    genFile,
+		})
+		return
+	}
+	m := matches[len(matches)-1]
+	ref := gen[m[2]:m[3]]
+	line := strings.Count(gen[:m[0]], "\n") + 1
+
+	if ok, err := pointsAt(ref, genFile, v.mapFile, mapContent); !ok {
+		msg := fmt.Sprintf("sourceMappingURL=%s doesn't point at %s", ref, v.mapFile)
+		if err != nil {
+			msg += ": " + err.Error()
+		}
+		v.report(finding{
+			Rule:    ruleSourceMappingURL,
+			Level:   "error",
+			Message: msg,
+			File:    genFile,
+			Line:    line,
+		})
+	}
+}
+
+// pointsAt reports whether the sourceMappingURL of the generated file
+// refers to the map. Inline maps are compared by content and remote maps
+// by name, because they are not fetched.
+func pointsAt(ref, genFile, mapFile string, mapContent []byte) (bool, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return false, err
+	}
+
+	switch u.Scheme {
+	case "data":
+		i := strings.IndexByte(u.Opaque, ',')
+		if i == -1 {
+			return false, fmt.Errorf("malformed data URL")
+		}
+		data := []byte(u.Opaque[i+1:])
+		if strings.HasSuffix(u.Opaque[:i], ";base64") {
+			data, err = base64.StdEncoding.DecodeString(string(data))
+			if err != nil {
+				return false, err
+			}
+		} else if s, err := url.PathUnescape(string(data)); err == nil {
+			data = []byte(s)
+		}
+		return bytes.Equal(bytes.TrimSpace(data), bytes.TrimSpace(mapContent)), nil
+	case "":
+		name := filepath.Join(filepath.Dir(genFile), filepath.FromSlash(u.Path))
+		if u.Path != "" && path.IsAbs(u.Path) {
+			// The path is relative to the root of the site.
+			return filepath.Base(name) == filepath.Base(mapFile), nil
+		}
+		a, err1 := filepath.Abs(name)
+		b, err2 := filepath.Abs(mapFile)
+		return err1 == nil && err2 == nil && a == b, nil
+	default:
+		return path.Base(u.Path) == filepath.Base(mapFile), nil
+	}
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name  string      `json:"name"`
+			Rules []sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+func newSARIF(findings []finding) *sarifLog {
+	var run sarifRun
+	run.Tool.Driver.Name = "sourcemap"
+	for _, rule := range []string{ruleParse, ruleGeneratedPos, ruleOriginalPos, ruleSourceMappingURL} {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               rule,
+			ShortDescription: sarifMessage{ruleDescriptions[rule]},
+		})
+	}
+
+	run.Results = []sarifResult{}
+	for _, f := range findings {
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(f.File)
+		if f.Line > 0 {
+			// SARIF columns are 1-based.
+			loc.PhysicalLocation.Region = &sarifRegion{
+				StartLine:   f.Line,
+				StartColumn: f.Column + 1,
+			}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.Rule,
+			Level:     f.Level,
+			Message:   sarifMessage{f.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	return &sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}
+}
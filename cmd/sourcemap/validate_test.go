@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{"typescript/context.js", "webpack-uglify/source-map.min.js", "index-map/bundle.js"} {
+		gen := filepath.Join("../../testdata/corpus", name)
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"validate", "-generated", gen, gen + ".map"}, nil, &stdout, &stderr)
+		if code != 0 {
+			t.Errorf("%s: got exit code %d: %s%s", name, code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestValidateFindings(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		name = filepath.Join(dir, name)
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	// The second segment is past the end of the generated line and
+	// of the source line, and the comment points at another map.
+	mapFile := write("app.js.map", `{
+  "version": 3,
+  "sources": ["app.ts"],
+  "sourcesContent": ["let a = 1;"],
+  "names": [],
+  "mappings": "AAAA,gBAAgB"
+}`)
+	gen := write("app.js", "var a=1;\n//# sourceMappingURL=other.js.map\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"validate", "-json", "-generated", gen, mapFile}, nil, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("got exit code %d: %s", code, stderr.String())
+	}
+
+	var findings []finding
+	if err := json.Unmarshal(stdout.Bytes(), &findings); err != nil {
+		t.Fatal(err)
+	}
+	rules := make(map[string]int)
+	for _, f := range findings {
+		rules[f.Rule]++
+	}
+	wanted := map[string]int{
+		ruleGeneratedPos:     1,
+		ruleOriginalPos:      1,
+		ruleSourceMappingURL: 1,
+	}
+	for rule, n := range wanted {
+		if rules[rule] != n {
+			t.Errorf("got %d %s findings, wanted %d: %+v", rules[rule], rule, n, findings)
+		}
+	}
+	for _, f := range findings {
+		var file string
+		switch f.Rule {
+		case ruleGeneratedPos:
+			file = gen
+		case ruleOriginalPos:
+			file = filepath.Join(dir, "app.ts")
+		default:
+			continue
+		}
+		if f.File != file || f.Line != 1 || f.Column != 16 {
+			t.Errorf("got %s finding at %s:%d:%d, wanted %s:1:16", f.Rule, f.File, f.Line, f.Column, file)
+		}
+	}
+
+	stdout.Reset()
+	code = run([]string{"validate", "-format", "sarif", "-generated", gen, mapFile}, nil, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), `"version": "2.1.0"`) ||
+		!strings.Contains(stdout.String(), `"ruleId": "source-mapping-url"`) ||
+		!strings.Contains(stdout.String(), `"startColumn": 17`) {
+		t.Fatalf("got exit code %d and %s", code, stdout.String())
+	}
+
+	mapFile = write("broken.js.map", `{"version": 3, "sources": [], "names": [], "mappings": "AAAA"}`)
+	stdout.Reset()
+	code = run([]string{"validate", mapFile}, nil, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stdout.String(), "[parse]") {
+		t.Fatalf("got exit code %d and %s", code, stdout.String())
+	}
+}
+
+func TestPointsAt(t *testing.T) {
+	mapContent := []byte(`{"version":3}`)
+	tests := []struct {
+		ref string
+		ok  bool
+	}{
+		{"app.js.map", true},
+		{"./app.js.map", true},
+		{"../dist/app.js.map", true},
+		{"/static/app.js.map", true},
+		{"https://example.com/app.js.map?v=1", true},
+		{"data:application/json;base64,eyJ2ZXJzaW9uIjozfQ==", true},
+		{"data:application/json;charset=utf-8,%7B%22version%22%3A3%7D", true},
+		{"other.js.map", false},
+		{"data:application/json;base64,e30=", false},
+	}
+	for _, test := range tests {
+		ok, _ := pointsAt(test.ref, "dist/app.js", "dist/app.js.map", mapContent)
+		if ok != test.ok {
+			t.Errorf("%s: got %v, wanted %v", test.ref, ok, test.ok)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap/visualize"
+)
+
+func visualizeFlags(e *env, fs *flag.FlagSet) {
+	fs.StringVar(&e.generated, "generated", "",
+		"the generated `file`, which is the map without .map by default")
+	fs.BoolVar(&e.html, "html", false, "write an HTML page instead of colored text")
+}
+
+func visualizeCmd(e *env, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	smap, err := readMap(args[0])
+	if err != nil {
+		return err
+	}
+
+	genFile := e.generated
+	if genFile == "" {
+		genFile = strings.TrimSuffix(args[0], ".map")
+	}
+	gen, err := os.ReadFile(genFile)
+	if err != nil {
+		return err
+	}
+
+	if e.html {
+		return visualize.HTML(e.stdout, smap, string(gen))
+	}
+	return visualize.Terminal(e.stdout, smap, string(gen))
+}
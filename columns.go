@@ -0,0 +1,27 @@
+package sourcemap
+
+import "github.com/go-sourcemap/sourcemap/internal/linecol"
+
+// ColumnUnit is the unit a column is measured in.
+type ColumnUnit int
+
+const (
+	// UTF16 columns count UTF-16 code units. Source maps use them.
+	UTF16 = ColumnUnit(linecol.UTF16)
+	// Bytes columns count UTF-8 bytes. Go tools such as go/token use them.
+	Bytes = ColumnUnit(linecol.Bytes)
+	// Runes columns count Unicode code points.
+	Runes = ColumnUnit(linecol.Runes)
+)
+
+// ConvertColumn converts the column on the 1-based line of text
+// from one unit to another. A column that points inside a character is
+// rounded down to the start of that character. Columns past the end of
+// the line are extended one unit at a time.
+func ConvertColumn(text string, line, column int, from, to ColumnUnit) int {
+	if from == to {
+		return column
+	}
+	s, _ := linecol.Line(text, line)
+	return linecol.ConvertColumn(s, column, linecol.Unit(from), linecol.Unit(to))
+}
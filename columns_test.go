@@ -0,0 +1,77 @@
+package sourcemap_test
+
+import (
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestConvertColumn(t *testing.T) {
+	// "é" is 2 bytes and 1 UTF-16 unit, "😀" is 4 bytes and 2 UTF-16 units.
+	text := "first\r\n\"é😀\";x()\nlast"
+
+	tests := []struct {
+		line, column int
+		from, to     sourcemap.ColumnUnit
+		want         int
+	}{
+		{2, 6, sourcemap.UTF16, sourcemap.Bytes, 9},
+		{2, 6, sourcemap.UTF16, sourcemap.Runes, 5},
+		{2, 9, sourcemap.Bytes, sourcemap.UTF16, 6},
+		{2, 5, sourcemap.Runes, sourcemap.UTF16, 6},
+		{2, 5, sourcemap.Runes, sourcemap.Bytes, 9},
+
+		// Inside a surrogate pair or a multi-byte sequence.
+		{2, 3, sourcemap.UTF16, sourcemap.Bytes, 3},
+		{2, 5, sourcemap.Bytes, sourcemap.Runes, 2},
+
+		// Past the end of the line and of the text.
+		{2, 12, sourcemap.UTF16, sourcemap.Bytes, 15},
+		{4, 3, sourcemap.UTF16, sourcemap.Bytes, 3},
+
+		{1, 3, sourcemap.UTF16, sourcemap.Bytes, 3},
+		{3, 2, sourcemap.Bytes, sourcemap.UTF16, 2},
+	}
+	for _, test := range tests {
+		got := sourcemap.ConvertColumn(text, test.line, test.column, test.from, test.to)
+		if got != test.want {
+			t.Errorf("ConvertColumn(%d, %d, %d, %d) = %d, wanted %d",
+				test.line, test.column, test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func TestSourceIn(t *testing.T) {
+	generated := "\"é😀\";x()"
+	original := "  \"é😀\"; x();"
+	smap, err := sourcemap.Parse("", []byte(`{
+  "version": 3,
+  "sources": ["a.js"],
+  "sourcesContent": `+j([]string{original})+`,
+  "names": [],
+  "mappings": "AAAE,MAAM"
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		unit         sourcemap.ColumnUnit
+		genColumn    int
+		wantedColumn int
+	}{
+		{sourcemap.UTF16, 6, 8},
+		{sourcemap.Bytes, 9, 11},
+		{sourcemap.Runes, 5, 7},
+	}
+	for _, test := range tests {
+		source, _, line, col, ok := smap.SourceIn(generated, 1, test.genColumn, test.unit)
+		if !ok {
+			t.Fatalf("unit=%d: source not found", test.unit)
+		}
+		if source != "a.js" || line != 1 || col != test.wantedColumn {
+			t.Fatalf("unit=%d: got %s:%d:%d, wanted a.js:1:%d",
+				test.unit, source, line, col, test.wantedColumn)
+		}
+	}
+}
@@ -1,23 +1,43 @@
 package sourcemap
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"path"
 	"sort"
+	"sync"
+
+	"github.com/go-sourcemap/sourcemap/internal/linecol"
 )
 
 type sourceMap struct {
 	Version        int               `json:"version"`
 	File           string            `json:"file"`
 	SourceRoot     string            `json:"sourceRoot"`
-	Sources        []string          `json:"sources"`
-	SourcesContent []string          `json:"sourcesContent"`
+	Sources        []*string         `json:"sources"`
+	SourcesContent []*string         `json:"sourcesContent"`
 	Names          []json.RawMessage `json:"names,string"`
 	Mappings       string            `json:"mappings"`
+	RangeMappings  string            `json:"rangeMappings"`
+	IgnoreList     []int             `json:"ignoreList"`
+	// Chrome's name of the field before it was standardized.
+	XGoogleIgnoreList []int  `json:"x_google_ignoreList"`
+	DebugID           string `json:"debugId"`
+	Scopes            string `json:"scopes"`
+	// Metro's function maps and Hermes' function offsets.
+	XFacebookSources       json.RawMessage `json:"x_facebook_sources"`
+	XHermesFunctionOffsets json.RawMessage `json:"x_hermes_function_offsets"`
+	// The line offsets of the modules of Metro RAM bundles.
+	XFacebookOffsets []*int `json:"x_facebook_offsets"`
+	// Sections of a section's map, which are not allowed.
+	Sections json.RawMessage `json:"sections"`
 
 	mappings []mapping
+	// extensions holds the vendor extension fields.
+	extensions map[string]json.RawMessage
+	// originalScopes holds the top-level scope of every source.
+	originalScopes []*OriginalScope
+	ranges         []*GeneratedRange
 }
 
 type v3 struct {
@@ -25,71 +45,70 @@ type v3 struct {
 	Sections []section `json:"sections"`
 }
 
-func (m *sourceMap) parse(sourcemapURL string) error {
+func (m *sourceMap) parse(sourcemapURL string, resolver SourcePathResolver) error {
 	if err := checkVersion(m.Version); err != nil {
 		return err
 	}
 
-	var sourceRootURL *url.URL
-	if m.SourceRoot != "" {
-		u, err := url.Parse(m.SourceRoot)
-		if err != nil {
-			return err
+	for _, src := range m.Sources {
+		if src == nil {
+			continue
 		}
-		if u.IsAbs() {
-			sourceRootURL = u
-		}
-	} else if sourcemapURL != "" {
-		u, err := url.Parse(sourcemapURL)
+		s, err := resolver.ResolveSource(sourcemapURL, m.SourceRoot, *src)
 		if err != nil {
 			return err
 		}
-		if u.IsAbs() {
-			u.Path = path.Dir(u.Path)
-			sourceRootURL = u
-		}
+		*src = s
 	}
 
-	for i, src := range m.Sources {
-		m.Sources[i] = m.absSource(sourceRootURL, src)
+	if m.IgnoreList == nil {
+		m.IgnoreList = m.XGoogleIgnoreList
+	}
+	for _, idx := range m.IgnoreList {
+		if idx < 0 || idx >= len(m.Sources) {
+			return fmt.Errorf("sourcemap: ignoreList index=%d is out of range", idx)
+		}
 	}
 
-	mappings, err := parseMappings(m.Mappings)
+	mappings, err := decodeMappings(m.Mappings)
 	if err != nil {
 		return err
 	}
+	if m.RangeMappings != "" {
+		if err := markRangeMappings(mappings, m.RangeMappings); err != nil {
+			return err
+		}
+	}
+	sortMappings(mappings)
+	for i := range mappings {
+		v := &mappings[i]
+		if int(v.sourcesInd) >= len(m.Sources) {
+			return fmt.Errorf("sourcemap: sources index=%d is out of range", v.sourcesInd)
+		}
+		if int(v.namesInd) >= len(m.Names) {
+			return fmt.Errorf("sourcemap: names index=%d is out of range", v.namesInd)
+		}
+	}
 
 	m.mappings = mappings
 	// Free memory.
 	m.Mappings = ""
+	m.RangeMappings = ""
 
-	return nil
-}
-
-func (m *sourceMap) absSource(root *url.URL, source string) string {
-	if path.IsAbs(source) {
-		return source
-	}
-
-	if u, err := url.Parse(source); err == nil && u.IsAbs() {
-		return source
-	}
-
-	if root != nil {
-		u := *root
-		u.Path = path.Join(u.Path, source)
-		return u.String()
-	}
-
-	if m.SourceRoot != "" {
-		return path.Join(m.SourceRoot, source)
+	if m.Scopes != "" {
+		s, err := parseScopes(m)
+		if err != nil {
+			return err
+		}
+		m.originalScopes = s.originals
+		m.ranges = s.ranges
 	}
 
-	return source
+	return nil
 }
 
 func (m *sourceMap) name(idx int) string {
-	if idx >= len(m.Names) {
+	if idx < 0 || idx >= len(m.Names) {
 		return ""
 	}
 
@@ -108,6 +127,15 @@ func (m *sourceMap) name(idx int) string {
 	return string(raw)
 }
 
+func (m *sourceMap) ignored(idx int) bool {
+	for _, i := range m.IgnoreList {
+		if i == idx {
+			return true
+		}
+	}
+	return false
+}
+
 type section struct {
 	Offset struct {
 		Line   int `json:"line"`
@@ -116,13 +144,77 @@ type section struct {
 	Map *sourceMap `json:"map"`
 }
 
+// SourceIndex describes an original source referenced by the map.
+type SourceIndex struct {
+	// URL is the resolved source as returned by Consumer.Source.
+	URL string
+	// Section is the index of the section that holds the source
+	// in the order of the map. It is 0 for maps without sections.
+	Section int
+	// Index is the index of the source in the section's sources.
+	Index int
+	// HasContent reports whether the map embeds the source content.
+	// It is false if the content is missing or null.
+	HasContent bool
+	// Ignored reports whether the source is in the ignore list,
+	// which marks third-party or generated code.
+	Ignored bool
+	// Null reports whether the entry of sources is null. URL is empty
+	// then, and mappings of the entry have no source.
+	Null bool
+}
+
 type Consumer struct {
 	sourcemapURL string
 	file         string
 	sections     []section
+	// indexed reports whether the map has sections.
+	indexed bool
+	debugID string
+	// top holds the top-level fields of the map.
+	top *sourceMap
+
+	sources     []SourceIndex
+	sourceIndex map[string]int
+
+	resolver SourcePathResolver
+	loader   ContentLoader
+
+	mu        sync.Mutex
+	lines     map[string]linecol.Index
+	loaded    map[string]string
+	originals map[string][]original
+	scanned   *scannedCode
+
+	originalScopes map[string]*OriginalScope
+	ranges         []*GeneratedRange
+
+	functionMaps  map[string][]functionMapping
+	hermesOffsets map[int][]int
 }
 
-func Parse(sourcemapURL string, b []byte) (*Consumer, error) {
+// Option configures a Consumer.
+type Option func(c *Consumer)
+
+// WithContentLoader sets the loader that is consulted for sources
+// whose content is missing from the map or is null.
+func WithContentLoader(loader ContentLoader) Option {
+	return func(c *Consumer) {
+		c.loader = loader
+	}
+}
+
+func Parse(sourcemapURL string, b []byte, opts ...Option) (*Consumer, error) {
+	c := &Consumer{
+		sourcemapURL: sourcemapURL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.resolver == nil {
+		c.resolver = URLResolver
+	}
+
 	v3 := new(v3)
 	err := unmarshalJSON(b, v3)
 	if err != nil {
@@ -132,26 +224,129 @@ func Parse(sourcemapURL string, b []byte) (*Consumer, error) {
 	if err := checkVersion(v3.Version); err != nil {
 		return nil, err
 	}
+	if bytes.Contains(b, []byte(`"`+extensionPrefix)) {
+		if err := v3.parseExtensions(b); err != nil {
+			return nil, err
+		}
+	}
 
+	c.indexed = len(v3.Sections) > 0
 	if len(v3.Sections) == 0 {
 		v3.Sections = append(v3.Sections, section{
 			Map: &v3.sourceMap,
 		})
 	}
 
-	for _, s := range v3.Sections {
-		err := s.Map.parse(sourcemapURL)
+	for i, s := range v3.Sections {
+		if s.Map == nil {
+			return nil, fmt.Errorf("sourcemap: section=%d has no map", i)
+		}
+		if s.Map.Sections != nil {
+			return nil, fmt.Errorf("sourcemap: section=%d is an index map", i)
+		}
+		err := s.Map.parse(sourcemapURL, c.resolver)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	reverse(v3.Sections)
-	return &Consumer{
-		sourcemapURL: sourcemapURL,
-		file:         v3.File,
-		sections:     v3.Sections,
-	}, nil
+	c.file = v3.File
+	c.debugID = v3.DebugID
+	c.sections = v3.Sections
+	c.top = &v3.sourceMap
+	c.indexSources()
+	c.indexScopes()
+	if err := c.parseHermes(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseHermes decodes the function maps of the sections
+// and the function offsets of the map.
+func (c *Consumer) parseHermes() error {
+	// Sections are stored in reverse order.
+	for i := len(c.sections) - 1; i >= 0; i-- {
+		m := c.sections[i].Map
+		if len(m.XFacebookSources) == 0 {
+			continue
+		}
+		maps, err := parseFunctionMaps(m.XFacebookSources, m.Sources)
+		if err != nil {
+			return err
+		}
+		if c.functionMaps == nil {
+			c.functionMaps = maps
+			continue
+		}
+		for src, mappings := range maps {
+			c.functionMaps[src] = mappings
+		}
+	}
+
+	if len(c.top.XHermesFunctionOffsets) > 0 {
+		offsets, err := parseHermesOffsets(c.top.XHermesFunctionOffsets)
+		if err != nil {
+			return err
+		}
+		c.hermesOffsets = offsets
+	}
+	return nil
+}
+
+func (c *Consumer) indexScopes() {
+	// Sections are stored in reverse order.
+	for i := len(c.sections) - 1; i >= 0; i-- {
+		s := &c.sections[i]
+		for _, scope := range s.Map.originalScopes {
+			if scope == nil {
+				continue
+			}
+			if c.originalScopes == nil {
+				c.originalScopes = make(map[string]*OriginalScope)
+			}
+			c.originalScopes[scope.Source] = scope
+		}
+		for _, r := range s.Map.ranges {
+			moveRange(r, s.Offset.Line, s.Offset.Column)
+			c.ranges = append(c.ranges, r)
+		}
+	}
+}
+
+func (c *Consumer) indexSources() {
+	c.sourceIndex = make(map[string]int)
+	// Sections are stored in reverse order.
+	for i := len(c.sections) - 1; i >= 0; i-- {
+		m := c.sections[i].Map
+		for j, src := range m.Sources {
+			si := SourceIndex{
+				Section:    len(c.sections) - 1 - i,
+				Index:      j,
+				HasContent: j < len(m.SourcesContent) && m.SourcesContent[j] != nil,
+				Ignored:    m.ignored(j),
+			}
+			// Null entries can't be looked up, so each is kept.
+			if src == nil {
+				si.Null = true
+				c.sources = append(c.sources, si)
+				continue
+			}
+			si.URL = *src
+
+			k, ok := c.sourceIndex[si.URL]
+			if !ok {
+				c.sourceIndex[si.URL] = len(c.sources)
+				c.sources = append(c.sources, si)
+				continue
+			}
+			// The last section that embeds the content wins.
+			if si.HasContent {
+				c.sources[k] = si
+			}
+		}
+	}
 }
 
 func (c *Consumer) SourcemapURL() string {
@@ -164,29 +359,173 @@ func (c *Consumer) File() string {
 	return c.file
 }
 
+// DebugID returns the debug ID that identifies the generated file
+// and its map, if the map has one.
+func (c *Consumer) DebugID() string {
+	return c.debugID
+}
+
+// Section describes a section of an index map.
+type Section struct {
+	// Line and Column are the 0-based offset of the section
+	// in the generated code.
+	Line, Column int
+	// File is the file of the section's map.
+	File string
+}
+
+// Sections returns the sections of an index map in the order of the map.
+// It returns nil for maps without sections.
+func (c *Consumer) Sections() []Section {
+	if !c.indexed {
+		return nil
+	}
+	sections := make([]Section, 0, len(c.sections))
+	for i := len(c.sections) - 1; i >= 0; i-- {
+		s := &c.sections[i]
+		sections = append(sections, Section{
+			Line:   s.Offset.Line,
+			Column: s.Offset.Column,
+			File:   s.Map.File,
+		})
+	}
+	return sections
+}
+
+// Mapping maps a position in the generated code to the original source.
+type Mapping struct {
+	GenLine   int `json:"genLine"`
+	GenColumn int `json:"genColumn"`
+	// Source is empty if the generated position is unmapped
+	// or the source is null.
+	Source string `json:"source"`
+	Name   string `json:"name,omitempty"`
+	// Line and Column are 0 if the generated position is unmapped.
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Range reports whether the mapping is a range mapping, which maps
+	// the generated positions up to the next mapping 1:1.
+	Range bool `json:"range,omitempty"`
+}
+
+// EachMapping calls fn for every mapping in generated order until
+// fn returns false. Lines are 1-based and columns are 0-based
+// as in Source.
+func (c *Consumer) EachMapping(fn func(m Mapping) bool) {
+	for i := len(c.sections) - 1; i >= 0; i-- {
+		s := &c.sections[i]
+		for j := range s.Map.mappings {
+			v := &s.Map.mappings[j]
+			m := Mapping{
+				GenLine:   int(v.genLine) + s.Offset.Line,
+				GenColumn: int(v.genColumn),
+				Line:      int(v.sourceLine),
+				Column:    int(v.sourceColumn),
+				Range:     v.isRange,
+			}
+			if v.genLine == 1 {
+				m.GenColumn += s.Offset.Column
+			}
+			if v.sourcesInd >= 0 {
+				if src := s.Map.Sources[v.sourcesInd]; src != nil {
+					m.Source = *src
+				}
+			}
+			if v.namesInd >= 0 {
+				m.Name = s.Map.name(int(v.namesInd))
+			}
+			if !fn(m) {
+				return
+			}
+		}
+	}
+}
+
 // Source returns the original source, name, line, and column information
 // for the generated source's line and column positions.
+// The source is empty if the mapping has no source or its source is null.
+// Inside a range mapping, the original position is interpolated and
+// the name is only returned at the start of the range.
 func (c *Consumer) Source(
 	genLine, genColumn int,
 ) (source, name string, line, column int, ok bool) {
+	s, match := c.find(genLine, genColumn)
+	if match == nil {
+		return
+	}
+	m := s.Map
+	if match.sourcesInd >= 0 {
+		if src := m.Sources[match.sourcesInd]; src != nil {
+			source = *src
+		}
+	}
+	line = int(match.sourceLine)
+	column = int(match.sourceColumn)
+	ok = true
+
+	genLine, genColumn = s.relative(genLine, genColumn)
+	exact := int(match.genLine) == genLine && int(match.genColumn) == genColumn
+	if match.namesInd >= 0 && (exact || !match.isRange) {
+		name = m.name(int(match.namesInd))
+	}
+	if match.isRange && match.sourcesInd >= 0 {
+		// The lines that follow the range mapping's line
+		// map to the lines that follow its original line.
+		if d := genLine - int(match.genLine); d > 0 {
+			line += d
+			column = genColumn
+		} else {
+			column += genColumn - int(match.genColumn)
+		}
+	}
+	return
+}
+
+// relative returns the generated position relative to the section.
+func (s *section) relative(genLine, genColumn int) (int, int) {
+	// The column offset only applies to the first line of the section.
+	if s.Offset.Line+1 == genLine {
+		genColumn -= s.Offset.Column
+	}
+	return genLine - s.Offset.Line, genColumn
+}
+
+// find returns the mapping that covers the generated position
+// and its section, or a nil mapping.
+func (c *Consumer) find(genLine, genColumn int) (*section, *mapping) {
 	for i := range c.sections {
 		s := &c.sections[i]
-		if s.Offset.Line < genLine ||
+		if s.Offset.Line+1 < genLine ||
 			(s.Offset.Line+1 == genLine && s.Offset.Column <= genColumn) {
-			genLine -= s.Offset.Line
-			genColumn -= s.Offset.Column
-			return c.source(s.Map, genLine, genColumn)
+			genLine, genColumn := s.relative(genLine, genColumn)
+			return s, lookup(s.Map, genLine, genColumn)
 		}
 	}
-	return
+	return nil, nil
 }
 
-func (c *Consumer) source(
-	m *sourceMap, genLine, genColumn int,
+// SourceIn is like Source, but genColumn and the returned column are
+// measured in unit instead of UTF-16 code units. generated is the text
+// of the generated file. The original column is converted using
+// the source content and is returned as is when the content is unknown.
+func (c *Consumer) SourceIn(
+	generated string, genLine, genColumn int, unit ColumnUnit,
 ) (source, name string, line, column int, ok bool) {
-	if len(m.mappings) == 0 {
+	genColumn = ConvertColumn(generated, genLine, genColumn, unit, UTF16)
+	source, name, line, column, ok = c.Source(genLine, genColumn)
+	if !ok || unit == UTF16 {
 		return
 	}
+	if s, ok := c.sourceLine(source, line); ok {
+		column = linecol.ConvertColumn(s, column, linecol.UTF16, linecol.Unit(unit))
+	}
+	return
+}
+
+func lookup(m *sourceMap, genLine, genColumn int) *mapping {
+	if len(m.mappings) == 0 {
+		return nil
+	}
 
 	i := sort.Search(len(m.mappings), func(i int) bool {
 		m := &m.mappings[i]
@@ -196,52 +535,124 @@ func (c *Consumer) source(
 		return int(m.genLine) >= genLine
 	})
 
-	var match *mapping
 	// Mapping not found
 	if i == len(m.mappings) {
 		// lets see if the line is correct but the column is bigger
-		match = &m.mappings[i-1]
+		match := &m.mappings[i-1]
 		if int(match.genLine) != genLine {
-			return
+			return nil
 		}
-	} else {
-		match = &m.mappings[i]
+		return match
+	}
 
-		// Fuzzy match.
-		if int(match.genLine) > genLine || int(match.genColumn) > genColumn {
-			if i == 0 {
-				return
-			}
-			match = &m.mappings[i-1]
+	match := &m.mappings[i]
+	// Fuzzy match.
+	if int(match.genLine) > genLine || int(match.genColumn) > genColumn {
+		if i == 0 {
+			return nil
 		}
+		match = &m.mappings[i-1]
 	}
+	return match
+}
 
-	if match.sourcesInd >= 0 {
-		source = m.Sources[match.sourcesInd]
+// SourceContent returns the original source content for the source.
+// Content that is not embedded in the map is loaded using
+// the ContentLoader, if one is configured.
+func (c *Consumer) SourceContent(source string) string {
+	content, _ := c.LookupSourceContent(source)
+	return content
+}
+
+// LookupSourceContent is like SourceContent, but it also reports
+// whether the content is available. It reports false when the content
+// is missing from the map or is null and no ContentLoader provided it,
+// which tells such sources apart from the ones that are genuinely empty.
+func (c *Consumer) LookupSourceContent(source string) (string, bool) {
+	content, err := c.LoadSourceContent(source)
+	return content, err == nil
+}
+
+// LoadSourceContent is like LookupSourceContent, but it returns the
+// error of the ContentLoader. The error wraps fs.ErrNotExist if the
+// source is unknown or its content is not embedded and no ContentLoader
+// is configured. Failed loads are retried on the next call.
+func (c *Consumer) LoadSourceContent(source string) (string, error) {
+	k, ok := c.sourceIndex[source]
+	if !ok {
+		return "", errNoContent(source)
 	}
-	if match.namesInd >= 0 {
-		name = m.name(int(match.namesInd))
+	si := &c.sources[k]
+	if si.HasContent {
+		m := c.sections[len(c.sections)-1-si.Section].Map
+		return *m.SourcesContent[si.Index], nil
 	}
-	line = int(match.sourceLine)
-	column = int(match.sourceColumn)
-	ok = true
-	return
+	return c.loadContent(source)
 }
 
-// SourceContent returns the original source content for the source.
-func (c *Consumer) SourceContent(source string) string {
-	for i := range c.sections {
-		s := &c.sections[i]
-		for i, src := range s.Map.Sources {
-			if src == source {
-				if i < len(s.Map.SourcesContent) {
-					return s.Map.SourcesContent[i]
-				}
-				break
-			}
+// Sources returns the distinct sources referenced by the map
+// in the order they first appear in it. Every null entry of sources
+// is returned too, with Null set.
+func (c *Consumer) Sources() []SourceIndex {
+	sources := make([]SourceIndex, len(c.sources))
+	copy(sources, c.sources)
+	return sources
+}
+
+// SourceLines returns the 1-based line of the source content together
+// with up to before lines preceding it and up to after lines following it.
+// It reports false if the content or the line is not available.
+func (c *Consumer) SourceLines(
+	source string, line, before, after int,
+) (pre []string, context string, post []string, ok bool) {
+	content, ix := c.sourceLineIndex(source)
+	context, ok = ix.Line(content, line)
+	if !ok {
+		return nil, "", nil, false
+	}
+	first := line - before
+	if first < 1 {
+		first = 1
+	}
+	for n := first; n < line; n++ {
+		s, _ := ix.Line(content, n)
+		pre = append(pre, s)
+	}
+	for n := line + 1; n <= line+after; n++ {
+		s, ok := ix.Line(content, n)
+		if !ok {
+			break
+		}
+		post = append(post, s)
+	}
+	return pre, context, post, true
+}
+
+func (c *Consumer) sourceLine(source string, line int) (string, bool) {
+	content, ix := c.sourceLineIndex(source)
+	return ix.Line(content, line)
+}
+
+// sourceLineIndex returns the source content and its line index,
+// which is built once per source.
+func (c *Consumer) sourceLineIndex(source string) (string, linecol.Index) {
+	content, ok := c.LookupSourceContent(source)
+	if !ok {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ix, ok := c.lines[source]
+	if !ok {
+		if c.lines == nil {
+			c.lines = make(map[string]linecol.Index)
 		}
+		ix = linecol.NewIndex(content)
+		c.lines[source] = ix
 	}
-	return ""
+	return content, ix
 }
 
 func checkVersion(version int) error {
@@ -3,29 +3,26 @@ package sourcemap_test
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/go-sourcemap/sourcemap"
 )
 
-const jqSourceMapURL = "http://code.jquery.com/jquery-2.0.3.min.map"
-
-var jqSourceMapBytes []byte
-
-func init() {
-	resp, err := http.Get(jqSourceMapURL)
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
+const (
+	webpackSourceMapURL  = "https://unpkg.com/source-map@0.6.1/dist/source-map.min.js.map"
+	webpackSourceMapFile = "testdata/corpus/webpack-uglify/source-map.min.js.map"
+)
 
-	jqSourceMapBytes, err = ioutil.ReadAll(resp.Body)
+func readFile(tb testing.TB, name string) []byte {
+	tb.Helper()
+	b, err := os.ReadFile(name)
 	if err != nil {
-		panic(err)
+		tb.Fatal(err)
 	}
+	return b
 }
 
 type sourceMapTest struct {
@@ -91,6 +88,20 @@ func TestIndexedSourceMap(t *testing.T) {
 	testSourceMap(t, indexedSourceMapJSON)
 }
 
+func TestMarshalJSON(t *testing.T) {
+	for _, json := range []string{sourceMapJSON, indexedSourceMapJSON} {
+		smap, err := sourcemap.Parse("", []byte(json))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := smap.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		testSourceMap(t, string(b))
+	}
+}
+
 func testSourceMap(t *testing.T, json string) {
 	smap, err := sourcemap.Parse("", []byte(json))
 	if err != nil {
@@ -141,6 +152,36 @@ func testSourceMap(t *testing.T, json string) {
 	}
 }
 
+func TestSources(t *testing.T) {
+	smap, err := sourcemap.Parse("", []byte(indexedSourceMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := smap.Sources()
+	wanted := []sourcemap.SourceIndex{
+		{URL: "/the/root/one.js", Section: 0, Index: 0, HasContent: true},
+		{URL: "/the/root/two.js", Section: 1, Index: 0, HasContent: true},
+	}
+	if !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %+v, wanted %+v", got, wanted)
+	}
+
+	jsonStr := strings.Replace(sourceMapJSON, `"sourcesContent": `, `"x_sourcesContent": `, 1)
+	smap, err = sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, si := range smap.Sources() {
+		if si.HasContent {
+			t.Fatalf("%s must not have content", si.URL)
+		}
+		if content := smap.SourceContent(si.URL); content != "" {
+			t.Fatalf("got content %q for %s", content, si.URL)
+		}
+	}
+}
+
 func TestSourceRootURL(t *testing.T) {
 	jsonStr := sourceMapJSON
 	jsonStr = strings.Replace(jsonStr, "/the/root", "http://the/root", 1)
@@ -185,39 +226,43 @@ func TestAbsSourceURL(t *testing.T) {
 	jsonStr = strings.Replace(jsonStr, "one.js", "http://the/root/one.js", 1)
 	jsonStr = strings.Replace(jsonStr, "two.js", "/another/root/two.js", 1)
 
-	testAbsSourceURL(t, "", jsonStr)
-	testAbsSourceURL(t, "http://path/to/map", jsonStr)
+	testAbsSourceURL(t, "", jsonStr, "/another/root/two.js")
+	testAbsSourceURL(t, "http://path/to/map", jsonStr, "http://path/another/root/two.js")
+
+	legacy := sourcemap.WithSourcePathResolver(sourcemap.LegacyResolver)
+	testAbsSourceURL(t, "", jsonStr, "/another/root/two.js", legacy)
+	testAbsSourceURL(t, "http://path/to/map", jsonStr, "/another/root/two.js", legacy)
 }
 
-func testAbsSourceURL(t *testing.T, mapURL, jsonStr string) {
-	smap, err := sourcemap.Parse(mapURL, []byte(jsonStr))
+func testAbsSourceURL(
+	t *testing.T, mapURL, jsonStr, wantedTwo string, opts ...sourcemap.Option,
+) {
+	smap, err := sourcemap.Parse(mapURL, []byte(jsonStr), opts...)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	tests := []*sourceMapTest{
 		{1, 1, "http://the/root/one.js", "", 1, 1},
-		{2, 1, "/another/root/two.js", "", 1, 1},
+		{2, 1, wantedTwo, "", 1, 1},
 	}
 	for _, test := range tests {
 		test.assert(t, smap)
 	}
 }
 
-func TestJQuerySourceMap(t *testing.T) {
-	smap, err := sourcemap.Parse(jqSourceMapURL, jqSourceMapBytes)
+func TestWebpackSourceMap(t *testing.T) {
+	smap, err := sourcemap.Parse(webpackSourceMapURL, readFile(t, webpackSourceMapFile))
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	tests := []*sourceMapTest{
-		{1, 1, "", "", 0, 0},
-		{4, 0, "", "", 0, 0},
-		{4, 1, "http://code.jquery.com/jquery-2.0.3.js", "", 14, 0},
-		{4, 10, "http://code.jquery.com/jquery-2.0.3.js", "window", 14, 11},
-		{5, 6789, "http://code.jquery.com/jquery-2.0.3.js", "apply", 4360, 27},
-		{5, 10006, "http://code.jquery.com/jquery-2.0.3.js", "apply", 4676, 8},
-		{4, 553, "http://code.jquery.com/jquery-2.0.3.js", "ready", 93, 9},
+		{1, 0, "", "", 0, 0},
+		{1, 1, "webpack:///webpack/universalModuleDefinition", "", 1, 0},
+		{1, 102, "webpack:///webpack/universalModuleDefinition", "define", 4, 0},
+		{1, 3196, "webpack:///lib/source-map-generator.js", "sourceRoot", 250, 0},
+		{1, 3197, "webpack:///lib/source-map-generator.js", "sourceRoot", 250, 0},
 		{999999, 0, "", "", 0, 0},
 	}
 	for _, test := range tests {
@@ -300,3 +345,217 @@ var indexedSourceMapJSON = `{
     }
   }]
 }`
+
+func TestSourceLines(t *testing.T) {
+	smap, err := sourcemap.Parse("", []byte(sourceMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pre, context, post, ok := smap.SourceLines("/the/root/one.js", 2, 5, 5)
+	if !ok {
+		t.Fatal("lines not found")
+	}
+	if !reflect.DeepEqual(pre, []string{"ONE.foo = function (bar) {"}) {
+		t.Fatalf("pre: got %q", pre)
+	}
+	if context != "  return baz(bar);" {
+		t.Fatalf("context: got %q", context)
+	}
+	if !reflect.DeepEqual(post, []string{"};"}) {
+		t.Fatalf("post: got %q", post)
+	}
+
+	pre, context, post, ok = smap.SourceLines("/the/root/two.js", 1, 1, 1)
+	if !ok || pre != nil || context != "TWO.inc = function (n) {" ||
+		!reflect.DeepEqual(post, []string{"  return n + 1;"}) {
+		t.Fatalf("got %q %q %q %v", pre, context, post, ok)
+	}
+
+	if _, _, _, ok := smap.SourceLines("/the/root/one.js", 4, 1, 1); ok {
+		t.Fatal("line must not exist")
+	}
+	if _, _, _, ok := smap.SourceLines("/the/root/three.js", 1, 1, 1); ok {
+		t.Fatal("source must not exist")
+	}
+}
+
+func TestNullSourcesAndContent(t *testing.T) {
+	jsonStr := `{
+  "version": 3,
+  "sources": ["empty.js", null, "missing.js", "unknown.js"],
+  "sourcesContent": ["", null, null],
+  "sourceRoot": "/the/root",
+  "names": [],
+  "mappings": "AAAA,CCAA,CCAA"
+}`
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []*sourceMapTest{
+		{1, 0, "/the/root/empty.js", "", 1, 0},
+		{1, 1, "", "", 1, 0},
+		{1, 2, "/the/root/missing.js", "", 1, 0},
+	}
+	for _, test := range tests {
+		test.assert(t, smap)
+	}
+
+	if content, ok := smap.LookupSourceContent("/the/root/empty.js"); !ok || content != "" {
+		t.Fatalf("empty.js: got %q, %v", content, ok)
+	}
+	for _, source := range []string{"/the/root/missing.js", "/the/root/unknown.js", "/the/root"} {
+		if content, ok := smap.LookupSourceContent(source); ok {
+			t.Fatalf("%s: got %q, %v", source, content, ok)
+		}
+	}
+
+	wanted := []sourcemap.SourceIndex{
+		{URL: "/the/root/empty.js", Index: 0, HasContent: true},
+		{Index: 1, Null: true},
+		{URL: "/the/root/missing.js", Index: 2},
+		{URL: "/the/root/unknown.js", Index: 3},
+	}
+	if got := smap.Sources(); !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %+v, wanted %+v", got, wanted)
+	}
+
+	_, context, _, ok := smap.SourceLines("/the/root/empty.js", 1, 0, 0)
+	if !ok || context != "" {
+		t.Fatalf("got %q, %v", context, ok)
+	}
+}
+
+func TestNullSourcesIndexMap(t *testing.T) {
+	jsonStr := strings.Replace(indexedSourceMapJSON, `"sources": ["two.js"]`, `"sources": [null]`, 1)
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []sourcemap.SourceIndex{
+		{URL: "/the/root/one.js", Section: 0, Index: 0, HasContent: true},
+		{Section: 1, Index: 0, HasContent: true, Null: true},
+	}
+	if got := smap.Sources(); !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %+v, wanted %+v", got, wanted)
+	}
+
+	test := &sourceMapTest{2, 1, "", "", 1, 1}
+	test.assert(t, smap)
+}
+
+func TestEachMapping(t *testing.T) {
+	smap, err := sourcemap.Parse("", []byte(indexedSourceMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mappings []sourcemap.Mapping
+	smap.EachMapping(func(m sourcemap.Mapping) bool {
+		mappings = append(mappings, m)
+		return true
+	})
+	if len(mappings) != 13 {
+		t.Fatalf("got %d mappings, wanted 13", len(mappings))
+	}
+	wanted := sourcemap.Mapping{
+		GenLine:   2,
+		GenColumn: 18,
+		Source:    "/the/root/two.js",
+		Name:      "n",
+		Line:      1,
+		Column:    21,
+	}
+	if mappings[10] != wanted {
+		t.Fatalf("got %+v, wanted %+v", mappings[10], wanted)
+	}
+
+	wantedSections := []sourcemap.Section{
+		{Line: 0, Column: 0, File: "min.js"},
+		{Line: 1, Column: 0, File: "min.js"},
+	}
+	if got := smap.Sections(); !reflect.DeepEqual(got, wantedSections) {
+		t.Fatalf("got %+v, wanted %+v", got, wantedSections)
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	smap, err := sourcemap.Parse("", []byte(indexedSourceMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		source             string
+		line, column       int
+		genLine, genColumn int
+		ok                 bool
+	}{
+		{"/the/root/one.js", 1, 1, 1, 1, true},
+		{"/the/root/one.js", 2, 10, 1, 28, true},
+		{"/the/root/one.js", 2, 11, 1, 28, true},
+		{"/the/root/two.js", 1, 21, 2, 18, true},
+		{"/the/root/two.js", 1, 0, 2, 1, true},
+		{"/the/root/two.js", 3, 0, 0, 0, false},
+		{"/the/root/three.js", 1, 1, 0, 0, false},
+	}
+	for _, test := range tests {
+		genLine, genColumn, ok := smap.Generated(test.source, test.line, test.column)
+		if genLine != test.genLine || genColumn != test.genColumn || ok != test.ok {
+			t.Errorf("%s:%d:%d: got %d:%d %v, wanted %d:%d %v",
+				test.source, test.line, test.column,
+				genLine, genColumn, ok, test.genLine, test.genColumn, test.ok)
+		}
+	}
+}
+
+func TestDebugID(t *testing.T) {
+	jsonStr := strings.Replace(sourceMapJSON, `"version": 3,`,
+		`"version": 3, "debugId": "85314830-023f-4cf1-a267-535f4e37bb17",`, 1)
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := smap.DebugID(); got != "85314830-023f-4cf1-a267-535f4e37bb17" {
+		t.Fatalf("got %q", got)
+	}
+	if smap.Sections() != nil {
+		t.Fatal("a map without sections has sections")
+	}
+}
+
+func TestRangeMappings(t *testing.T) {
+	jsonStr := `{
+  "version": 3,
+  "sources": ["one.js"],
+  "names": ["a"],
+  "mappings": "AAAAA;;AACA",
+  "rangeMappings": "B"
+}`
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []*sourceMapTest{
+		{1, 0, "one.js", "a", 1, 0},
+		{1, 5, "one.js", "", 1, 5},
+		// The line without mappings follows the range mapping.
+		{2, 4, "one.js", "", 2, 4},
+		{3, 2, "one.js", "", 2, 0},
+	}
+	for _, test := range tests {
+		test.assert(t, smap)
+	}
+
+	b, err := smap.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"rangeMappings":"B"`) {
+		t.Errorf("got %s", b)
+	}
+}
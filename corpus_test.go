@@ -0,0 +1,103 @@
+package sourcemap_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+// corpusDir holds real-world source maps, one per directory,
+// together with golden lookups generated by mozilla/source-map.
+const corpusDir = "testdata/corpus"
+
+// corpusLookup is a row of golden.json:
+// [genLine, genColumn, source, name, line, column].
+type corpusLookup struct {
+	genLine, genColumn int
+	source, name       *string
+	line, column       *int
+}
+
+func (l *corpusLookup) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &[]interface{}{
+		&l.genLine, &l.genColumn, &l.source, &l.name, &l.line, &l.column,
+	})
+}
+
+func TestCorpus(t *testing.T) {
+	dirs, err := os.ReadDir(corpusDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		dir := filepath.Join(corpusDir, dir.Name())
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			testCorpusMap(t, dir)
+		})
+	}
+}
+
+func testCorpusMap(t *testing.T, dir string) {
+	smap, err := parseCorpusMap(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "golden.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lookups []corpusLookup
+	if err := json.Unmarshal(b, &lookups); err != nil {
+		t.Fatal(err)
+	}
+	if len(lookups) == 0 {
+		t.Fatal("golden.json has no lookups")
+	}
+
+	for _, l := range lookups {
+		source, name, line, col, ok := smap.Source(l.genLine, l.genColumn)
+		got := fmt.Sprintf("%s %q %d:%d", source, name, line, col)
+		if l.source == nil {
+			if ok && source != "" {
+				t.Errorf("%d:%d: got %s, wanted no mapping", l.genLine, l.genColumn, got)
+			}
+			continue
+		}
+
+		var wantedName string
+		if l.name != nil {
+			wantedName = *l.name
+		}
+		wanted := fmt.Sprintf("%s %q %d:%d", *l.source, wantedName, *l.line, *l.column)
+		if !ok || got != wanted {
+			t.Errorf("%d:%d: got %s, wanted %s", l.genLine, l.genColumn, got, wanted)
+		}
+	}
+}
+
+// parseCorpusMap parses the source map in dir. Sources are kept as they
+// appear in the map, which is how mozilla/source-map reports them.
+func parseCorpusMap(dir string) (*sourcemap.Consumer, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.map"))
+	if err != nil {
+		return nil, err
+	}
+	if len(names) != 1 {
+		return nil, fmt.Errorf("%s: got %d source maps, wanted 1", dir, len(names))
+	}
+
+	b, err := os.ReadFile(names[0])
+	if err != nil {
+		return nil, err
+	}
+	return sourcemap.Parse(filepath.Base(names[0]), b,
+		sourcemap.WithSourcePathResolver(sourcemap.RawResolver))
+}
@@ -0,0 +1,123 @@
+package sourcemap
+
+import "sort"
+
+// MappingChange is a generated position that maps differently.
+type MappingChange struct {
+	GenLine   int `json:"genLine"`
+	GenColumn int `json:"genColumn"`
+	// A and B are the mappings at the position in the compared maps.
+	// One of them is nil if the position has no mapping in that map.
+	A *Mapping `json:"a"`
+	B *Mapping `json:"b"`
+}
+
+// Difference holds the differences between two maps. Lists are sorted
+// by source or by generated position.
+type Difference struct {
+	AddedSources   []string `json:"addedSources,omitempty"`
+	RemovedSources []string `json:"removedSources,omitempty"`
+	// ChangedContent holds the sources whose content differs.
+	ChangedContent []string `json:"changedContent,omitempty"`
+	// Mappings holds the positions whose original source, line, column
+	// or range flag differ, including the positions that are mapped in one map only.
+	Mappings []MappingChange `json:"mappings,omitempty"`
+	// Names holds the positions that only differ by name.
+	Names []MappingChange `json:"names,omitempty"`
+}
+
+// Empty reports whether the maps are equivalent.
+func (d *Difference) Empty() bool {
+	return len(d.AddedSources) == 0 &&
+		len(d.RemovedSources) == 0 &&
+		len(d.ChangedContent) == 0 &&
+		len(d.Mappings) == 0 &&
+		len(d.Names) == 0
+}
+
+type genPos struct {
+	line, column int
+}
+
+// Diff compares the decoded mappings, sources and source content of two
+// maps. Differences in the encoding, such as the order of the sources
+// and names or of the segments of a line, are ignored. If a generated
+// position has several mappings, the first one is compared.
+func Diff(a, b *Consumer) *Difference {
+	d := new(Difference)
+
+	for _, si := range b.sources {
+		if _, ok := a.sourceIndex[si.URL]; !ok {
+			d.AddedSources = append(d.AddedSources, si.URL)
+		}
+	}
+	for _, si := range a.sources {
+		if _, ok := b.sourceIndex[si.URL]; !ok {
+			d.RemovedSources = append(d.RemovedSources, si.URL)
+			continue
+		}
+		ca, okA := a.LookupSourceContent(si.URL)
+		cb, okB := b.LookupSourceContent(si.URL)
+		if okA != okB || ca != cb {
+			d.ChangedContent = append(d.ChangedContent, si.URL)
+		}
+	}
+	sort.Strings(d.AddedSources)
+	sort.Strings(d.RemovedSources)
+	sort.Strings(d.ChangedContent)
+
+	mappings := make(map[genPos]*Mapping)
+	a.EachMapping(func(m Mapping) bool {
+		pos := genPos{m.GenLine, m.GenColumn}
+		if _, ok := mappings[pos]; !ok {
+			mappings[pos] = &m
+		}
+		return true
+	})
+
+	seen := make(map[genPos]bool)
+	b.EachMapping(func(m Mapping) bool {
+		pos := genPos{m.GenLine, m.GenColumn}
+		if seen[pos] {
+			return true
+		}
+		seen[pos] = true
+
+		ma := mappings[pos]
+		delete(mappings, pos)
+		change := MappingChange{
+			GenLine:   pos.line,
+			GenColumn: pos.column,
+			A:         ma,
+			B:         &m,
+		}
+		switch {
+		case ma == nil || ma.Source != m.Source || ma.Line != m.Line || ma.Column != m.Column ||
+			ma.Range != m.Range:
+			d.Mappings = append(d.Mappings, change)
+		case ma.Name != m.Name:
+			d.Names = append(d.Names, change)
+		}
+		return true
+	})
+	for pos, ma := range mappings {
+		d.Mappings = append(d.Mappings, MappingChange{
+			GenLine:   pos.line,
+			GenColumn: pos.column,
+			A:         ma,
+		})
+	}
+
+	sortChanges(d.Mappings)
+	sortChanges(d.Names)
+	return d
+}
+
+func sortChanges(changes []MappingChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].GenLine == changes[j].GenLine {
+			return changes[i].GenColumn < changes[j].GenColumn
+		}
+		return changes[i].GenLine < changes[j].GenLine
+	})
+}
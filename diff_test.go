@@ -0,0 +1,65 @@
+package sourcemap_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := sourcemap.Parse("", []byte(sourceMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same map with the sources in the reverse order.
+	reordered := `{
+  "version": 3,
+  "sources": ["two.js", "one.js"],
+  "sourcesContent": ` + j([]string{twoSourceContent, oneSourceContent}) + `,
+  "sourceRoot": "/the/root",
+  "names": ["bar", "baz", "n"],
+  "mappings": "CCAC,IAAI,IAAM,SAAUA,GAClB,OAAOC,IAAID;CDDb,IAAI,IAAM,SAAUE,GAClB,OAAOA"
+}`
+	b, err := sourcemap.Parse("", []byte(reordered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := sourcemap.Diff(a, b); !d.Empty() {
+		t.Fatalf("got %+v, wanted no differences", d)
+	}
+
+	jsonStr := strings.Replace(sourceMapJSON, `"baz"`, `"qux"`, 1)
+	jsonStr = strings.Replace(jsonStr, `"two.js"`, `"three.js"`, 1)
+	jsonStr = strings.Replace(jsonStr, "return baz(bar)", "return qux(bar)", 1)
+	b, err = sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := sourcemap.Diff(a, b)
+	if !reflect.DeepEqual(d.AddedSources, []string{"/the/root/three.js"}) ||
+		!reflect.DeepEqual(d.RemovedSources, []string{"/the/root/two.js"}) ||
+		!reflect.DeepEqual(d.ChangedContent, []string{"/the/root/one.js"}) {
+		t.Fatalf("sources: got %+v", d)
+	}
+
+	if len(d.Names) != 1 {
+		t.Fatalf("got %d name changes, wanted 1", len(d.Names))
+	}
+	if n := d.Names[0]; n.GenLine != 1 || n.A.Name != "baz" || n.B.Name != "qux" {
+		t.Fatalf("got %+v -> %+v", n.A, n.B)
+	}
+
+	// The mappings of the second line point at the renamed source.
+	if len(d.Mappings) != 6 {
+		t.Fatalf("got %d mapping changes, wanted 6", len(d.Mappings))
+	}
+	for _, c := range d.Mappings {
+		if c.GenLine != 2 || c.A.Source != "/the/root/two.js" || c.B.Source != "/the/root/three.js" {
+			t.Fatalf("got %+v -> %+v", c.A, c.B)
+		}
+	}
+}
@@ -2,32 +2,45 @@ package sourcemap_test
 
 import (
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"os"
 
 	"github.com/go-sourcemap/sourcemap"
 )
 
 func ExampleParse() {
-	mapURL := "http://code.jquery.com/jquery-2.0.3.min.map"
-	resp, err := http.Get(mapURL)
+	mapURL := "https://unpkg.com/source-map@0.6.1/dist/source-map.min.js.map"
+	b, err := os.ReadFile("testdata/corpus/webpack-uglify/source-map.min.js.map")
 	if err != nil {
 		panic(err)
 	}
-	defer resp.Body.Close()
 
-	b, err := ioutil.ReadAll(resp.Body)
+	smap, err := sourcemap.Parse(mapURL, b)
 	if err != nil {
 		panic(err)
 	}
 
-	smap, err := sourcemap.Parse(mapURL, b)
-	if err != nil {
+	line, column := 1, 3196
+	file, name, line, col, ok := smap.Source(line, column)
+	fmt.Println(file, name, line, col, ok)
+	// Output: webpack:///lib/source-map-generator.js sourceRoot 250 0 true
+}
+
+func ExampleStreamingGenerator() {
+	g := sourcemap.NewStreamingGenerator(os.Stdout, "app.js")
+	mappings := []sourcemap.Mapping{
+		{GenLine: 1, GenColumn: 0, Source: "app.go", Line: 3, Column: 1},
+		{GenLine: 2, GenColumn: 4, Source: "app.go", Line: 4, Column: 1, Name: "x"},
+	}
+	for _, m := range mappings {
+		if err := g.AddMapping(m); err != nil {
+			panic(err)
+		}
+	}
+	if err := g.SetSourceContent("app.go", "package main\n"); err != nil {
 		panic(err)
 	}
-
-	line, column := 5, 6789
-	file, fn, line, col, ok := smap.Source(line, column)
-	fmt.Println(file, fn, line, col, ok)
-	// Output: http://code.jquery.com/jquery-2.0.3.js apply 4360 27 true
+	if err := g.Close(); err != nil {
+		panic(err)
+	}
+	// Output: {"version":3,"file":"app.js","mappings":"AAEC;IACAA","sources":["app.go"],"names":["x"],"sourcesContent":["package main\n"]}
 }
@@ -0,0 +1,101 @@
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// extensionPrefix is the prefix of the names of vendor extension fields.
+const extensionPrefix = "x_"
+
+// parseExtensions collects the extension fields of the map
+// and of the maps of its sections.
+func (v *v3) parseExtensions(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := unmarshalJSON(b, &raw); err != nil {
+		return err
+	}
+	v.extensions = extensions(raw)
+
+	if len(v.Sections) == 0 {
+		return nil
+	}
+	var sections []struct {
+		Map map[string]json.RawMessage `json:"map"`
+	}
+	if err := unmarshalJSON(raw["sections"], &sections); err != nil {
+		return err
+	}
+	for i := range sections {
+		if i < len(v.Sections) && v.Sections[i].Map != nil {
+			v.Sections[i].Map.extensions = extensions(sections[i].Map)
+		}
+	}
+	return nil
+}
+
+func extensions(raw map[string]json.RawMessage) map[string]json.RawMessage {
+	var ext map[string]json.RawMessage
+	for k, v := range raw {
+		if !strings.HasPrefix(k, extensionPrefix) {
+			continue
+		}
+		if ext == nil {
+			ext = make(map[string]json.RawMessage)
+		}
+		ext[k] = v
+	}
+	return ext
+}
+
+// appendExtensions adds the extension fields to the encoded object.
+func appendExtensions(b []byte, ext map[string]json.RawMessage) ([]byte, error) {
+	if len(ext) == 0 {
+		return b, nil
+	}
+	keys := make([]string, 0, len(ext))
+	for k := range ext {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := bytes.NewBuffer(b[:len(b)-1])
+	for _, k := range keys {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalJSON(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := json.Compact(buf, ext[k]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Extension returns the vendor extension field of the map, such as
+// "x_google_ignoreList", whose name starts with "x_". For index maps,
+// the fields of the index map itself are returned.
+func (c *Consumer) Extension(name string) (json.RawMessage, bool) {
+	v, ok := c.top.extensions[name]
+	return v, ok
+}
+
+// SectionExtension is like Extension for the map of the section with
+// the index in the order of the map. The map itself is the only section
+// of maps without sections.
+func (c *Consumer) SectionExtension(i int, name string) (json.RawMessage, bool) {
+	if i < 0 || i >= len(c.sections) {
+		return nil, false
+	}
+	// Sections are stored in reverse order.
+	v, ok := c.sections[len(c.sections)-1-i].Map.extensions[name]
+	return v, ok
+}
@@ -0,0 +1,72 @@
+package sourcemap_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestExtension(t *testing.T) {
+	jsonStr := `{
+  "version": 3,
+  "x_custom": {"a": [1, 2]},
+  "sections": [
+    {"offset": {"line": 0, "column": 0}, "map": {"version": 3, "sources": ["a.js"], "names": [], "mappings": "AAAA", "x_google_ignoreList": [0]}},
+    {"offset": {"line": 1, "column": 0}, "map": {"version": 3, "sources": ["b.js"], "names": [], "mappings": "AAAA", "x_webpack_id": 7}}
+  ]
+}`
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := smap.Extension("x_custom"); !ok || string(v) != `{"a": [1, 2]}` {
+		t.Errorf("got %s, %v", v, ok)
+	}
+	if _, ok := smap.Extension("x_webpack_id"); ok {
+		t.Error("x_webpack_id is a field of a section")
+	}
+	if _, ok := smap.Extension("version"); ok {
+		t.Error("version is not an extension")
+	}
+	if v, ok := smap.SectionExtension(0, "x_google_ignoreList"); !ok || string(v) != "[0]" {
+		t.Errorf("got %s, %v", v, ok)
+	}
+	if v, ok := smap.SectionExtension(1, "x_webpack_id"); !ok || string(v) != "7" {
+		t.Errorf("got %s, %v", v, ok)
+	}
+	if _, ok := smap.SectionExtension(2, "x_webpack_id"); ok {
+		t.Error("section 2 must not exist")
+	}
+
+	b, err := smap.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`"x_custom":{"a":[1,2]}}`,
+		`"ignoreList":[0],"x_google_ignoreList":[0]}`,
+		`"x_webpack_id":7}`,
+	} {
+		if !strings.Contains(string(b), s) {
+			t.Errorf("%s is missing in %s", s, b)
+		}
+	}
+
+	// The extensions of maps without sections belong to their only section.
+	smap, err = sourcemap.Parse("", []byte(`{"version": 3, "sources": [], "names": [], "mappings": "", "x_a": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := smap.SectionExtension(0, "x_a"); !ok || string(v) != "true" {
+		t.Errorf("got %s, %v", v, ok)
+	}
+	b, err = smap.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wanted := `{"version":3,"sources":[],"names":[],"mappings":"","x_a":true}`; string(b) != wanted {
+		t.Errorf("got %s, wanted %s", b, wanted)
+	}
+}
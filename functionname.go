@@ -0,0 +1,90 @@
+package sourcemap
+
+import (
+	"sort"
+
+	"github.com/go-sourcemap/sourcemap/internal/jsscan"
+	"github.com/go-sourcemap/sourcemap/internal/linecol"
+)
+
+// scannedCode holds the functions of the generated code.
+type scannedCode struct {
+	text  string
+	lines linecol.Index
+	funcs []jsscan.Func
+}
+
+// FunctionName returns the original name of the function that encloses
+// the generated position, which is better suited for stack frames than
+// the name returned by Source, as that is the name of the token at the
+// position. generated is the text of the generated file.
+//
+// The function is found in the generated code and the name of its
+// binding or property is mapped to the original name. If the map has
+// no name for it, the identifier at the original position in the source
+// content is used and else the name in the generated code.
+// It returns false if the position is not in a named function.
+func (c *Consumer) FunctionName(generated string, genLine, genColumn int) (string, bool) {
+	sc := c.scan(generated)
+	text, ok := sc.lines.Line(generated, genLine)
+	if !ok {
+		return "", false
+	}
+	offset := sc.lines[genLine-1] + linecol.ConvertColumn(text, genColumn, linecol.UTF16, linecol.Bytes)
+	f := jsscan.Enclosing(sc.funcs, offset)
+	if f == nil || f.Anonymous() {
+		return "", false
+	}
+
+	nameLine := sort.SearchInts(sc.lines, f.NameStart+1)
+	lineStart := sc.lines[nameLine-1]
+	nameColumn := linecol.ConvertColumn(
+		generated[lineStart:f.NameStart], f.NameStart-lineStart, linecol.Bytes, linecol.UTF16)
+	if name, ok := c.originalName(nameLine, nameColumn); ok {
+		return name, true
+	}
+	return generated[f.NameStart:f.NameEnd], true
+}
+
+// originalName returns the original name of the identifier at
+// the generated position if a mapping starts at the position.
+func (c *Consumer) originalName(genLine, genColumn int) (string, bool) {
+	s, match := c.find(genLine, genColumn)
+	if match == nil {
+		return "", false
+	}
+	line, column := s.relative(genLine, genColumn)
+	if int(match.genLine) != line || int(match.genColumn) != column {
+		return "", false
+	}
+
+	if match.namesInd >= 0 {
+		return s.Map.name(int(match.namesInd)), true
+	}
+	if match.sourcesInd < 0 || s.Map.Sources[match.sourcesInd] == nil {
+		return "", false
+	}
+	text, ok := c.sourceLine(*s.Map.Sources[match.sourcesInd], int(match.sourceLine))
+	if !ok {
+		return "", false
+	}
+	offset := linecol.ConvertColumn(text, int(match.sourceColumn), linecol.UTF16, linecol.Bytes)
+	name := jsscan.Ident(text, offset)
+	return name, name != ""
+}
+
+// scan returns the functions of the generated code,
+// which are kept for the last code that was scanned.
+func (c *Consumer) scan(generated string) *scannedCode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.scanned == nil || c.scanned.text != generated {
+		c.scanned = &scannedCode{
+			text:  generated,
+			lines: linecol.NewIndex(generated),
+			funcs: jsscan.Scan(generated),
+		}
+	}
+	return c.scanned
+}
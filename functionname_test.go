@@ -0,0 +1,54 @@
+package sourcemap_test
+
+import (
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestFunctionName(t *testing.T) {
+	const source = `function outer() {
+  const helper = () => {
+    throw new Error('x');
+  };
+  helper();
+}`
+	const generated = `function o(){const n=()=>{throw new Error('x')};n()};function q(){z()}`
+	jsonStr := `{
+  "version": 3,
+  "sources": ["one.js"],
+  "sourcesContent": ` + j([]string{source}) + `,
+  "names": ["outer"],
+  "mappings": "SAASA,UACD,OACJ"
+}`
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		genColumn int
+		name      string
+		ok        bool
+	}{
+		// The name is taken from the source content.
+		{26, "helper", true},
+		// The name is taken from names.
+		{48, "outer", true},
+		{0, "outer", true},
+		// The name is not mapped.
+		{66, "q", true},
+		{52, "", false},
+	}
+	for _, test := range tests {
+		name, ok := smap.FunctionName(generated, 1, test.genColumn)
+		if name != test.name || ok != test.ok {
+			t.Errorf("column %d: got %q, %v, wanted %q, %v",
+				test.genColumn, name, ok, test.name, test.ok)
+		}
+	}
+
+	if _, ok := smap.FunctionName(generated, 2, 0); ok {
+		t.Error("line 2 must not exist")
+	}
+}
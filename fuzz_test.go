@@ -0,0 +1,73 @@
+package sourcemap_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func FuzzParse(f *testing.F) {
+	for _, pattern := range []string{
+		"testdata/source-map-tests/resources/*.map",
+		"testdata/corpus/*/*.map",
+	} {
+		names, err := filepath.Glob(pattern)
+		if err != nil {
+			f.Fatal(err)
+		}
+		for _, name := range names {
+			b, err := os.ReadFile(name)
+			if err != nil {
+				f.Fatal(err)
+			}
+			f.Add(b)
+		}
+	}
+	f.Add([]byte(sourceMapJSON))
+	f.Add([]byte(indexedSourceMapJSON))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		smap, err := sourcemap.Parse("https://example.com/min.js.map", b)
+		if err != nil {
+			return
+		}
+		checkSources(t, smap)
+
+		b1, err := smap.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		smap, err = sourcemap.Parse("", b1, sourcemap.WithSourcePathResolver(sourcemap.RawResolver))
+		if err != nil {
+			t.Fatalf("can't parse the serialized map %s: %s", b1, err)
+		}
+		b2, err := smap.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b1, b2) {
+			t.Fatalf("got %s after a round-trip, wanted %s", b2, b1)
+		}
+	})
+}
+
+// checkSources checks that every mapping found by Source
+// references a source of the map.
+func checkSources(t *testing.T, smap *sourcemap.Consumer) {
+	sources := make(map[string]bool)
+	for _, si := range smap.Sources() {
+		sources[si.URL] = true
+	}
+
+	for line := 0; line <= 10; line++ {
+		for _, col := range []int{-1, 0, 1, 2, 5, 10, 100, 1000} {
+			source, _, _, _, ok := smap.Source(line, col)
+			if ok && source != "" && !sources[source] {
+				t.Fatalf("%d:%d: got source %q, which is not in the map", line, col, source)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package sourcemap
+
+import "sort"
+
+// original is a mapping indexed by its original position.
+type original struct {
+	line, column       int
+	genLine, genColumn int
+}
+
+// Generated returns the generated position for the original position.
+// If there is no mapping at the original column, the closest mapping
+// that precedes it on the line is used, or else the first one that follows.
+// Lines are 1-based and columns are 0-based as in Source.
+func (c *Consumer) Generated(source string, line, column int) (genLine, genColumn int, ok bool) {
+	originals := c.originalIndex()[source]
+
+	i := sort.Search(len(originals), func(i int) bool {
+		o := &originals[i]
+		if o.line == line {
+			return o.column > column
+		}
+		return o.line > line
+	})
+	switch {
+	case i > 0 && originals[i-1].line == line:
+		i--
+	case i < len(originals) && originals[i].line == line:
+	default:
+		return 0, 0, false
+	}
+	return originals[i].genLine, originals[i].genColumn, true
+}
+
+// originalIndex returns the mappings of every source sorted by
+// the original position, which is built on the first use.
+func (c *Consumer) originalIndex() map[string][]original {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.originals != nil {
+		return c.originals
+	}
+
+	originals := make(map[string][]original)
+	c.EachMapping(func(m Mapping) bool {
+		if m.Source != "" {
+			originals[m.Source] = append(originals[m.Source], original{
+				line:      m.Line,
+				column:    m.Column,
+				genLine:   m.GenLine,
+				genColumn: m.GenColumn,
+			})
+		}
+		return true
+	})
+	for _, o := range originals {
+		sort.SliceStable(o, func(i, j int) bool {
+			if o[i].line == o[j].line {
+				return o[i].column < o[j].column
+			}
+			return o[i].line < o[j].line
+		})
+	}
+	c.originals = originals
+	return originals
+}
@@ -0,0 +1,215 @@
+package sourcemap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StreamingGenerator writes a source map while the generated code
+// is produced. The segments are written to the writer as they are
+// added, so only the sources, the names and the source content are kept
+// in memory, together with the rangeMappings field if range mappings
+// are added, which is written after the mappings. The mappings are
+// written before the other fields, which are written by Close.
+type StreamingGenerator struct {
+	w    *bufio.Writer
+	out  *errWriter
+	file string
+
+	mappings *mappingsEncoder
+	ranges   *rangesEncoder
+	rangeBuf strings.Builder
+
+	sources     []string
+	sourceIndex map[string]int
+	names       []string
+	nameIndex   map[string]int
+	contents    map[string]string
+
+	// lastLine and lastColumn are the generated position of the last mapping.
+	lastLine, lastColumn int
+	started              bool
+	closed               bool
+}
+
+// NewStreamingGenerator returns a generator that writes the map of
+// the generated file to w.
+func NewStreamingGenerator(w io.Writer, file string) *StreamingGenerator {
+	out := &errWriter{w: w}
+	g := &StreamingGenerator{
+		w:           bufio.NewWriter(out),
+		out:         out,
+		file:        file,
+		sourceIndex: make(map[string]int),
+		nameIndex:   make(map[string]int),
+		lastLine:    1,
+	}
+	g.mappings = newMappingsEncoder(g.w)
+	g.ranges = newRangesEncoder(&g.rangeBuf)
+	return g
+}
+
+func (g *StreamingGenerator) start() error {
+	if g.started {
+		return nil
+	}
+	g.started = true
+	file := ""
+	if g.file != "" {
+		b, err := marshalJSON(g.file)
+		if err != nil {
+			return err
+		}
+		file = `"file":` + string(b) + `,`
+	}
+	_, err := fmt.Fprintf(g.w, `{"version":3,%s"mappings":"`, file)
+	return err
+}
+
+// AddMapping adds a mapping, which must not precede the mappings added
+// before it in the generated code. Lines are 1-based and columns are
+// 0-based as in Source. A mapping without a source marks the generated
+// position as unmapped and must not have a name. Range mappings are
+// written to the rangeMappings field.
+func (g *StreamingGenerator) AddMapping(m Mapping) error {
+	if g.closed {
+		return errGeneratorClosed
+	}
+	switch {
+	case m.GenLine < 1 || m.GenColumn < 0 ||
+		m.Source != "" && (m.Line < 1 || m.Column < 0):
+		return fmt.Errorf(
+			"sourcemap: mapping at line=%d column=%d has a negative value", m.GenLine, m.GenColumn)
+	case m.GenLine < g.lastLine || m.GenLine == g.lastLine && m.GenColumn < g.lastColumn:
+		return fmt.Errorf(
+			"sourcemap: mapping at line=%d column=%d is out of order", m.GenLine, m.GenColumn)
+	case m.Source == "" && m.Name != "":
+		return fmt.Errorf(
+			"sourcemap: mapping at line=%d column=%d has a name but no source", m.GenLine, m.GenColumn)
+	}
+	if err := g.start(); err != nil {
+		return err
+	}
+	g.lastLine, g.lastColumn = m.GenLine, m.GenColumn
+
+	v := mapping{
+		genLine:    int32(m.GenLine),
+		genColumn:  int32(m.GenColumn),
+		sourcesInd: -1,
+		namesInd:   -1,
+		isRange:    m.Range,
+	}
+	if m.Source != "" {
+		v.sourcesInd = int32(g.addSource(m.Source))
+		v.sourceLine = int32(m.Line)
+		v.sourceColumn = int32(m.Column)
+		if m.Name != "" {
+			v.namesInd = int32(g.addName(m.Name))
+		}
+	}
+	g.mappings.encode(&v)
+	g.ranges.encode(&v)
+	// Errors of the writer are reported once the buffer is written.
+	return g.out.err
+}
+
+var errGeneratorClosed = errors.New("sourcemap: generator is closed")
+
+// errWriter keeps the first error of the writer.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.err = err
+	return n, err
+}
+
+func (g *StreamingGenerator) addSource(source string) int {
+	i, ok := g.sourceIndex[source]
+	if !ok {
+		i = len(g.sources)
+		g.sourceIndex[source] = i
+		g.sources = append(g.sources, source)
+	}
+	return i
+}
+
+func (g *StreamingGenerator) addName(name string) int {
+	i, ok := g.nameIndex[name]
+	if !ok {
+		i = len(g.names)
+		g.nameIndex[name] = i
+		g.names = append(g.names, name)
+	}
+	return i
+}
+
+// SetSourceContent sets the content of the source, which is written
+// by Close. The source is added to the map if no mapping uses it.
+func (g *StreamingGenerator) SetSourceContent(source, content string) error {
+	if g.closed {
+		return errGeneratorClosed
+	}
+	g.addSource(source)
+	if g.contents == nil {
+		g.contents = make(map[string]string)
+	}
+	g.contents[source] = content
+	return nil
+}
+
+// Close writes the fields of the map that follow the mappings.
+// It does not close the underlying writer.
+func (g *StreamingGenerator) Close() error {
+	if g.closed {
+		return nil
+	}
+	if err := g.start(); err != nil {
+		return err
+	}
+	g.closed = true
+
+	if g.sources == nil {
+		g.sources = []string{}
+	}
+	if g.names == nil {
+		g.names = []string{}
+	}
+	sources, err := marshalJSON(g.sources)
+	if err != nil {
+		return err
+	}
+	names, err := marshalJSON(g.names)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(g.w, `","sources":%s,"names":%s`, sources, names)
+
+	if g.contents != nil {
+		contents := make([]*string, len(g.sources))
+		for i, src := range g.sources {
+			if content, ok := g.contents[src]; ok {
+				contents[i] = &content
+			}
+		}
+		b, err := marshalJSON(contents)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(g.w, `,"sourcesContent":%s`, b)
+	}
+	if g.rangeBuf.Len() > 0 {
+		fmt.Fprintf(g.w, `,"rangeMappings":"%s"`, g.rangeBuf.String())
+	}
+	g.w.WriteByte('}')
+	return g.w.Flush()
+}
@@ -0,0 +1,84 @@
+package sourcemap_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestStreamingGenerator(t *testing.T) {
+	var buf bytes.Buffer
+	g := sourcemap.NewStreamingGenerator(&buf, "min.js")
+	for _, m := range []sourcemap.Mapping{
+		{GenLine: 1, GenColumn: 0, Source: "one.js", Line: 1, Column: 0, Name: "foo"},
+		{GenLine: 1, GenColumn: 4, Source: "two.js", Line: 3, Column: 2, Range: true},
+		{GenLine: 1, GenColumn: 10},
+		{GenLine: 3, GenColumn: 1, Source: "one.js", Line: 2, Column: 0, Name: "foo"},
+	} {
+		if err := g.AddMapping(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.SetSourceContent("two.js", "let a;\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetSourceContent("three.js", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	err := g.AddMapping(sourcemap.Mapping{GenLine: 3, GenColumn: 0})
+	if err == nil {
+		t.Fatal("a mapping out of order must fail")
+	}
+	err = g.AddMapping(sourcemap.Mapping{GenLine: 3, GenColumn: 5, Name: "foo"})
+	if err == nil {
+		t.Fatal("a mapping with a name but no source must fail")
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetSourceContent("four.js", ""); err == nil {
+		t.Fatal("setting the content after Close must fail")
+	}
+
+	wanted := `{"version":3,"file":"min.js","mappings":"AAAAA,ICEE,M;;CDDFA",` +
+		`"sources":["one.js","two.js","three.js"],"names":["foo"],` +
+		`"sourcesContent":[null,"let a;\n",""],"rangeMappings":"C"}`
+	if buf.String() != wanted {
+		t.Fatalf("got  %s\nwanted %s", buf.String(), wanted)
+	}
+
+	smap, err := sourcemap.Parse("", buf.Bytes(), sourcemap.WithSourcePathResolver(sourcemap.RawResolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []*sourceMapTest{
+		{1, 2, "one.js", "foo", 1, 0},
+		{1, 7, "two.js", "", 3, 5},
+		{3, 1, "one.js", "foo", 2, 0},
+	}
+	for _, test := range tests {
+		test.assert(t, smap)
+	}
+	if content := smap.SourceContent("two.js"); content != "let a;\n" {
+		t.Errorf("got content %q", content)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("failed")
+}
+
+func TestStreamingGeneratorWriteError(t *testing.T) {
+	g := sourcemap.NewStreamingGenerator(failingWriter{}, "")
+	if err := g.AddMapping(sourcemap.Mapping{GenLine: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Close(); err == nil {
+		t.Fatal("wanted an error")
+	}
+}
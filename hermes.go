@@ -0,0 +1,195 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap/base64vlq"
+)
+
+// functionMap is the function map of a source, which is the first item
+// of its metadata in the x_facebook_sources field of Metro maps.
+type functionMap struct {
+	Names    []string `json:"names"`
+	Mappings string   `json:"mappings"`
+}
+
+// functionMapping is the start of the code of a function
+// in an original source.
+type functionMapping struct {
+	line, column int
+	name         string
+}
+
+// parseFunctionMaps decodes the function maps of the sources,
+// which may be null.
+func parseFunctionMaps(raw json.RawMessage, sources []*string) (map[string][]functionMapping, error) {
+	var metadata []json.RawMessage
+	if err := unmarshalJSON(raw, &metadata); err != nil {
+		return nil, err
+	}
+
+	maps := make(map[string][]functionMapping)
+	for i, md := range metadata {
+		if i >= len(sources) {
+			return nil, fmt.Errorf("sourcemap: x_facebook_sources has more items than sources")
+		}
+		var items []json.RawMessage
+		if err := unmarshalJSON(md, &items); err != nil {
+			return nil, err
+		}
+		if len(items) == 0 || sources[i] == nil || string(items[0]) == "null" {
+			continue
+		}
+
+		fm := new(functionMap)
+		if err := unmarshalJSON(items[0], fm); err != nil {
+			return nil, err
+		}
+		mappings, err := decodeFunctionMap(fm)
+		if err != nil {
+			return nil, fmt.Errorf("sourcemap: function map of source=%d: %w", i, err)
+		}
+		maps[*sources[i]] = mappings
+	}
+	return maps, nil
+}
+
+// decodeFunctionMap decodes the mappings of a function map. Lines are
+// separated by ";" and segments by ",". Every segment holds the column,
+// which restarts on every line, the index of the name and optionally
+// the original line, all relative to the previous segment.
+func decodeFunctionMap(fm *functionMap) ([]functionMapping, error) {
+	rd := strings.NewReader("")
+	dec := base64vlq.NewDecoder(rd)
+
+	var mappings []functionMapping
+	line, nameInd := 1, 0
+	for _, lineMappings := range strings.Split(fm.Mappings, ";") {
+		column := 0
+		for _, segment := range strings.Split(lineMappings, ",") {
+			if segment == "" {
+				continue
+			}
+			rd.Reset(segment)
+			var v [3]int32
+			n := 0
+			for ; rd.Len() > 0 && n < len(v); n++ {
+				d, err := dec.Decode()
+				if err != nil {
+					return nil, err
+				}
+				v[n] = d
+			}
+			if n < 2 || rd.Len() > 0 {
+				return nil, fmt.Errorf("segment %q has a wrong number of fields", segment)
+			}
+
+			column += int(v[0])
+			nameInd += int(v[1])
+			line += int(v[2])
+			if nameInd < 0 || nameInd >= len(fm.Names) {
+				return nil, fmt.Errorf("names index=%d is out of range", nameInd)
+			}
+			mappings = append(mappings, functionMapping{
+				line:   line,
+				column: column,
+				name:   fm.Names[nameInd],
+			})
+		}
+	}
+
+	less := func(i, j int) bool {
+		if mappings[i].line == mappings[j].line {
+			return mappings[i].column < mappings[j].column
+		}
+		return mappings[i].line < mappings[j].line
+	}
+	if !sort.SliceIsSorted(mappings, less) {
+		sort.SliceStable(mappings, less)
+	}
+	return mappings, nil
+}
+
+// parseHermesOffsets decodes the x_hermes_function_offsets field,
+// which holds the bytecode offsets of the functions of every segment.
+func parseHermesOffsets(raw json.RawMessage) (map[int][]int, error) {
+	var v map[string][]int
+	if err := unmarshalJSON(raw, &v); err != nil {
+		return nil, err
+	}
+	offsets := make(map[int][]int, len(v))
+	for k, fnOffsets := range v {
+		segment, err := strconv.Atoi(k)
+		if err != nil || segment < 0 {
+			return nil, fmt.Errorf("sourcemap: x_hermes_function_offsets has segment=%q", k)
+		}
+		offsets[segment] = fnOffsets
+	}
+	return offsets, nil
+}
+
+// OriginalFunctionName returns the name of the function that contains
+// the original position according to the function map of the source,
+// which Metro writes to the x_facebook_sources field. Code outside of
+// functions is usually named "<global>".
+// Lines are 1-based and columns are 0-based as in Source.
+func (c *Consumer) OriginalFunctionName(source string, line, column int) (string, bool) {
+	mappings := c.functionMaps[source]
+	i := sort.Search(len(mappings), func(i int) bool {
+		m := &mappings[i]
+		if m.line == line {
+			return m.column > column
+		}
+		return m.line > line
+	})
+	if i == 0 {
+		return "", false
+	}
+	return mappings[i-1].name, true
+}
+
+// HermesFrame is the original location of a Hermes bytecode frame.
+type HermesFrame struct {
+	Source       string
+	Line, Column int
+	// Name is the name at the position as returned by Source.
+	Name string
+	// FunctionName is the original name of the function,
+	// which is empty if the source has no function map.
+	FunctionName string
+}
+
+// HermesSource returns the original location of a frame of Hermes bytecode,
+// which is given by the segment, the function and the bytecode offset in
+// the function. The functions of the segment are looked up in the
+// x_hermes_function_offsets field of the map.
+func (c *Consumer) HermesSource(segmentID, functionID, bytecodeOffset int) (*HermesFrame, bool) {
+	fnOffsets := c.hermesOffsets[segmentID]
+	if functionID < 0 || functionID >= len(fnOffsets) {
+		return nil, false
+	}
+	return c.HermesVirtualSource(segmentID, fnOffsets[functionID]+bytecodeOffset)
+}
+
+// HermesVirtualSource is like HermesSource, but takes the virtual offset
+// of the frame in the segment, which is the offset of the function plus
+// the bytecode offset, as printed in Hermes stack traces.
+// Bytecode maps have a line per segment and a column per offset.
+func (c *Consumer) HermesVirtualSource(segmentID, virtualOffset int) (*HermesFrame, bool) {
+	source, name, line, column, ok := c.Source(segmentID+1, virtualOffset)
+	if !ok {
+		return nil, false
+	}
+	f := &HermesFrame{
+		Source: source,
+		Line:   line,
+		Column: column,
+		Name:   name,
+	}
+	f.FunctionName, _ = c.OriginalFunctionName(source, line, column)
+	return f, true
+}
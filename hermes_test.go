@@ -0,0 +1,88 @@
+package sourcemap_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+// hermesMapJSON is a bytecode map of the source
+//
+//	function a() {
+//	  function b() {}
+//	}
+//
+// with a function map and the offsets of two functions.
+const hermesMapJSON = `{
+  "version": 3,
+  "sources": ["app.js", "other.js"],
+  "names": [],
+  "mappings": "AAAA,UACK",
+  "x_facebook_sources": [
+    [{"names": ["<global>", "a", "b"], "mappings": "AA,UC;ECC,eD;CDC"}],
+    null
+  ],
+  "x_hermes_function_offsets": {"0": [0, 8]}
+}`
+
+func TestOriginalFunctionName(t *testing.T) {
+	smap, err := sourcemap.Parse("", []byte(hermesMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		source       string
+		line, column int
+		name         string
+	}{
+		{"app.js", 1, 5, "<global>"},
+		{"app.js", 1, 10, "a"},
+		{"app.js", 2, 5, "b"},
+		{"app.js", 2, 20, "a"},
+		{"app.js", 3, 1, "<global>"},
+		{"other.js", 1, 0, ""},
+	}
+	for _, test := range tests {
+		name, ok := smap.OriginalFunctionName(test.source, test.line, test.column)
+		if name != test.name || ok != (test.name != "") {
+			t.Errorf("%s:%d:%d: got %q, %v, wanted %q",
+				test.source, test.line, test.column, name, ok, test.name)
+		}
+	}
+}
+
+func TestHermesSource(t *testing.T) {
+	smap, err := sourcemap.Parse("", []byte(hermesMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, ok := smap.HermesSource(0, 1, 3)
+	wanted := &sourcemap.HermesFrame{Source: "app.js", Line: 2, Column: 5, FunctionName: "b"}
+	if !ok || !reflect.DeepEqual(f, wanted) {
+		t.Errorf("got %+v, wanted %+v", f, wanted)
+	}
+
+	f, ok = smap.HermesVirtualSource(0, 4)
+	wanted = &sourcemap.HermesFrame{Source: "app.js", Line: 1, Column: 0, FunctionName: "<global>"}
+	if !ok || !reflect.DeepEqual(f, wanted) {
+		t.Errorf("got %+v, wanted %+v", f, wanted)
+	}
+
+	for _, ids := range [][2]int{{0, 2}, {1, 0}, {0, -1}} {
+		if _, ok := smap.HermesSource(ids[0], ids[1], 0); ok {
+			t.Errorf("segment=%d function=%d must not exist", ids[0], ids[1])
+		}
+	}
+
+	b, err := smap.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"x_hermes_function_offsets":{"0":[0,8]}`) {
+		t.Errorf("got %s", b)
+	}
+}
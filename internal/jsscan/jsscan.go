@@ -0,0 +1,399 @@
+// Package jsscan finds the functions of JavaScript code. It tokenizes
+// the code and matches brackets instead of parsing it, which is enough
+// for the minified code that source maps are generated for.
+package jsscan
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Func is a function in the code. Offsets are in bytes.
+type Func struct {
+	// Start is the offset of the function keyword, the method name or
+	// the arrow function parameters and End is the offset after the body.
+	Start, End int
+	// NameStart and NameEnd are the offsets of the name, which is
+	// the name of the binding or the property for function expressions.
+	// Both are -1 for anonymous functions.
+	NameStart, NameEnd int
+}
+
+// Anonymous reports whether the function has no name.
+func (f *Func) Anonymous() bool {
+	return f.NameStart < 0
+}
+
+type kind int
+
+const (
+	ident kind = iota
+	punct
+	literal
+)
+
+type token struct {
+	kind       kind
+	start, end int
+}
+
+// regexpKeywords are the keywords after which a slash starts a regexp.
+var regexpKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true,
+	"new": true, "delete": true, "void": true, "throw": true, "case": true,
+	"do": true, "else": true, "yield": true, "await": true,
+}
+
+// controlKeywords are followed by parentheses and a block,
+// which makes them look like methods.
+var controlKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"with": true, "function": true, "return": true, "typeof": true,
+}
+
+type scanner struct {
+	src    string
+	tokens []token
+}
+
+func (s *scanner) text(t token) string {
+	return s.src[t.start:t.end]
+}
+
+func (s *scanner) is(i int, text string) bool {
+	return i >= 0 && i < len(s.tokens) && s.text(s.tokens[i]) == text
+}
+
+func (s *scanner) isIdent(i int) bool {
+	return i >= 0 && i < len(s.tokens) && s.tokens[i].kind == ident
+}
+
+// regexpAllowed reports whether a slash starts a regexp
+// rather than a division after the last token.
+func (s *scanner) regexpAllowed() bool {
+	if len(s.tokens) == 0 {
+		return true
+	}
+	t := s.tokens[len(s.tokens)-1]
+	switch t.kind {
+	case ident:
+		return regexpKeywords[s.text(t)]
+	case literal:
+		return false
+	}
+	switch s.text(t) {
+	case ")", "]", "}":
+		return false
+	}
+	return true
+}
+
+func (s *scanner) tokenize() {
+	src := s.src
+	// braces holds whether each open brace starts a template substitution.
+	var braces []bool
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return
+			}
+			i += 2 + end + 2
+		case c == '\'' || c == '"':
+			start := i
+			i = skipString(src, i+1, c)
+			s.tokens = append(s.tokens, token{literal, start, i})
+		case c == '`':
+			start := i
+			var sub bool
+			i, sub = skipTemplate(src, i+1)
+			s.tokens = append(s.tokens, token{literal, start, i})
+			if sub {
+				braces = append(braces, true)
+			}
+		case c == '}' && len(braces) > 0 && braces[len(braces)-1]:
+			// The end of a template substitution.
+			braces = braces[:len(braces)-1]
+			start := i
+			var sub bool
+			i, sub = skipTemplate(src, i+1)
+			s.tokens = append(s.tokens, token{literal, start, i})
+			if sub {
+				braces = append(braces, true)
+			}
+		case c == '/' && s.regexpAllowed():
+			start := i
+			i = skipRegexp(src, i+1)
+			s.tokens = append(s.tokens, token{literal, start, i})
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (isIdentPart(src[i]) || src[i] == '.' ||
+				(src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E')) {
+				i++
+			}
+			s.tokens = append(s.tokens, token{literal, start, i})
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				if src[i] >= utf8.RuneSelf {
+					_, size := utf8.DecodeRuneInString(src[i:])
+					i += size
+				} else {
+					i++
+				}
+			}
+			s.tokens = append(s.tokens, token{ident, start, i})
+		default:
+			start := i
+			switch {
+			case hasPrefix(src, i, "=>"):
+				i += 2
+			case hasPrefix(src, i, "=="):
+				for i < len(src) && src[i] == '=' {
+					i++
+				}
+			default:
+				if c == '{' {
+					braces = append(braces, false)
+				} else if c == '}' && len(braces) > 0 {
+					braces = braces[:len(braces)-1]
+				}
+				i++
+			}
+			s.tokens = append(s.tokens, token{punct, start, i})
+		}
+	}
+}
+
+// Scan returns the functions of the code sorted by offset.
+// Arrow functions are only found if their body is a block.
+func Scan(src string) []Func {
+	s := &scanner{src: src}
+	s.tokenize()
+	match := s.matchBrackets()
+
+	var funcs []Func
+	for i, t := range s.tokens {
+		switch text := s.text(t); {
+		case t.kind == ident && text == "function":
+			j := i + 1
+			if s.is(j, "*") {
+				j++
+			}
+			name := -1
+			if s.isIdent(j) {
+				name = j
+				j++
+			}
+			if !s.is(j, "(") || match[j] == -1 || !s.is(match[j]+1, "{") {
+				continue
+			}
+			start := i
+			if s.is(i-1, "async") {
+				start = i - 1
+			}
+			if name == -1 {
+				name = s.bindingName(start)
+			}
+			funcs = append(funcs, s.newFunc(i, match[match[j]+1], name))
+		case t.kind == punct && text == "=>":
+			if !s.is(i+1, "{") {
+				continue
+			}
+			start := i - 1
+			if s.is(start, ")") {
+				start = match[start]
+			} else if !s.isIdent(start) {
+				continue
+			}
+			if start < 0 {
+				continue
+			}
+			if s.is(start-1, "async") {
+				start--
+			}
+			funcs = append(funcs, s.newFunc(start, match[i+1], s.bindingName(start)))
+		case t.kind == ident && !controlKeywords[text] && s.is(i+1, "("):
+			// A method: name(params) { body }.
+			if close := match[i+1]; close == -1 || !s.is(close+1, "{") ||
+				s.is(i-1, ".") || s.is(i-1, "function") || s.is(i-1, "*") && s.is(i-2, "function") {
+				continue
+			}
+			funcs = append(funcs, s.newFunc(i, match[match[i+1]+1], i))
+		}
+	}
+
+	sort.SliceStable(funcs, func(i, j int) bool {
+		return funcs[i].Start < funcs[j].Start
+	})
+	return funcs
+}
+
+func (s *scanner) newFunc(start, end, name int) Func {
+	f := Func{
+		Start:     s.tokens[start].start,
+		End:       len(s.src),
+		NameStart: -1,
+		NameEnd:   -1,
+	}
+	if end >= 0 {
+		f.End = s.tokens[end].end
+	}
+	if name >= 0 {
+		f.NameStart = s.tokens[name].start
+		f.NameEnd = s.tokens[name].end
+	}
+	return f
+}
+
+// bindingName returns the name that a function expression starting at
+// the token is assigned to in "name = ", "obj.name = " or "name: ",
+// or -1 if there is none.
+func (s *scanner) bindingName(start int) int {
+	if (s.is(start-1, "=") || s.is(start-1, ":")) && s.isIdent(start-2) {
+		return start - 2
+	}
+	return -1
+}
+
+// matchBrackets returns the index of the matching bracket of every
+// bracket token and -1 for other tokens and unbalanced brackets.
+func (s *scanner) matchBrackets() []int {
+	match := make([]int, len(s.tokens))
+	var stack []int
+	for i, t := range s.tokens {
+		match[i] = -1
+		if t.kind != punct {
+			continue
+		}
+		var open byte
+		switch c := s.src[t.start]; c {
+		case '(', '[', '{':
+			stack = append(stack, i)
+			continue
+		case ')':
+			open = '('
+		case ']':
+			open = '['
+		case '}':
+			open = '{'
+		default:
+			continue
+		}
+		for len(stack) > 0 {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if s.src[s.tokens[j].start] == open {
+				match[i], match[j] = j, i
+				break
+			}
+		}
+	}
+	return match
+}
+
+// Enclosing returns the innermost function of funcs, as returned by Scan,
+// that contains the offset, or nil.
+func Enclosing(funcs []Func, offset int) *Func {
+	var f *Func
+	for i := range funcs {
+		if funcs[i].Start > offset {
+			break
+		}
+		if offset < funcs[i].End {
+			f = &funcs[i]
+		}
+	}
+	return f
+}
+
+func skipString(src string, i int, quote byte) int {
+	for i < len(src) {
+		switch src[i] {
+		case '\\':
+			i += 2
+		case quote, '\n':
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(src)
+}
+
+// skipTemplate skips the template up to and including the closing
+// backquote or the start of a substitution, which it reports.
+func skipTemplate(src string, i int) (int, bool) {
+	for i < len(src) {
+		switch {
+		case src[i] == '\\':
+			i += 2
+		case src[i] == '`':
+			return i + 1, false
+		case src[i] == '$' && i+1 < len(src) && src[i+1] == '{':
+			return i + 2, true
+		default:
+			i++
+		}
+	}
+	return len(src), false
+}
+
+func skipRegexp(src string, i int) int {
+	class := false
+	for i < len(src) {
+		switch c := src[i]; {
+		case c == '\\':
+			i += 2
+			continue
+		case c == '\n' || c == '\r':
+			return i
+		case c == '[':
+			class = true
+		case c == ']':
+			class = false
+		case c == '/' && !class:
+			i++
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			return i
+		}
+		i++
+	}
+	return len(src)
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		c == '_' || c == '$' || c == '#' || c >= utf8.RuneSelf
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+func hasPrefix(s string, i int, prefix string) bool {
+	return len(s)-i >= len(prefix) && s[i:i+len(prefix)] == prefix
+}
+
+// Ident returns the identifier that starts at the offset or "".
+func Ident(src string, offset int) string {
+	if offset < 0 || offset >= len(src) || !isIdentStart(src[offset]) {
+		return ""
+	}
+	end := offset
+	for end < len(src) && isIdentPart(src[end]) {
+		end++
+	}
+	return src[offset:end]
+}
@@ -0,0 +1,70 @@
+package jsscan_test
+
+import (
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap/internal/jsscan"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src   string
+		names []string
+	}{
+		{`function a(){} function*b(){} async function c(){}`, []string{"a", "b", "c"}},
+		{`x.y=function(){};var z=function(){}`, []string{"y", "z"}},
+		{`({m(a){if(a){}},n:()=>{},o:async x=>{}})`, []string{"m", "n", "o"}},
+		{`[function(){},()=>{},a=>a]`, []string{"", ""}},
+		{`class A{constructor(){}get b(){for(;;){}}}`, []string{"constructor", "b"}},
+		// Brackets in strings, templates, comments and regexps are ignored.
+		{`function a(){"}";'{';` + "`${`}`}}`" + `;/*}*/ //}
+/}/.test(x)}function b(){}`, []string{"a", "b"}},
+		// A slash after an expression is a division.
+		{`function a(){return x/2/y}function b(){}`, []string{"a", "b"}},
+	}
+	for _, test := range tests {
+		funcs := jsscan.Scan(test.src)
+		var names []string
+		for _, f := range funcs {
+			if f.Anonymous() {
+				names = append(names, "")
+			} else {
+				names = append(names, test.src[f.NameStart:f.NameEnd])
+			}
+		}
+		if len(names) != len(test.names) {
+			t.Errorf("%s: got %q, wanted %q", test.src, names, test.names)
+			continue
+		}
+		for i := range names {
+			if names[i] != test.names[i] {
+				t.Errorf("%s: got %q, wanted %q", test.src, names, test.names)
+				break
+			}
+		}
+	}
+}
+
+func TestEnclosing(t *testing.T) {
+	src := `function a(){var b=function(){x()};y()};z()`
+	funcs := jsscan.Scan(src)
+
+	tests := []struct {
+		offset int
+		name   string
+	}{
+		{0, "a"},
+		{29, "b"},
+		{35, "a"},
+		{40, ""},
+	}
+	for _, test := range tests {
+		name := ""
+		if f := jsscan.Enclosing(funcs, test.offset); f != nil {
+			name = src[f.NameStart:f.NameEnd]
+		}
+		if name != test.name {
+			t.Errorf("offset %d: got %q, wanted %q", test.offset, name, test.name)
+		}
+	}
+}
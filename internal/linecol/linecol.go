@@ -0,0 +1,137 @@
+// Package linecol splits text into lines, which end with "\n", "\r\n"
+// or "\r", and converts columns between UTF-16 code units, bytes and runes.
+package linecol
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Unit is the unit a column is measured in.
+type Unit int
+
+const (
+	UTF16 Unit = iota
+	Bytes
+	Runes
+)
+
+// Index holds the offsets at which the lines of a text start.
+type Index []int
+
+func NewIndex(text string) Index {
+	ix := Index{0}
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+		default:
+			continue
+		}
+		ix = append(ix, i+1)
+	}
+	return ix
+}
+
+// Line returns the 1-based line of text without its terminator.
+func (ix Index) Line(text string, n int) (string, bool) {
+	if n < 1 || n > len(ix) {
+		return "", false
+	}
+	start, end := ix[n-1], len(text)
+	if n < len(ix) {
+		end = ix[n]
+	}
+	s := text[start:end]
+	for len(s) > 0 && (s[len(s)-1] == '\n' || s[len(s)-1] == '\r') {
+		s = s[:len(s)-1]
+	}
+	return s, true
+}
+
+// Split returns the lines of text without their terminators.
+func Split(text string) []string {
+	ix := NewIndex(text)
+	lines := make([]string, len(ix))
+	for i := range ix {
+		lines[i], _ = ix.Line(text, i+1)
+	}
+	return lines
+}
+
+// Line returns the 1-based line of text without its terminator.
+// Unlike Index, it does not allocate.
+func Line(text string, n int) (string, bool) {
+	if n < 1 {
+		return "", false
+	}
+	for ; n > 1; n-- {
+		i := strings.IndexAny(text, "\r\n")
+		if i == -1 {
+			return "", false
+		}
+		if text[i] == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+			i++
+		}
+		text = text[i+1:]
+	}
+	if i := strings.IndexAny(text, "\r\n"); i != -1 {
+		text = text[:i]
+	}
+	return text, true
+}
+
+// ConvertColumn converts the column on the line s from one unit to
+// another. A column that points inside a character is rounded down to
+// the start of that character. Columns past the end of the line are
+// extended one unit at a time.
+func ConvertColumn(s string, column int, from, to Unit) int {
+	if from == to {
+		return column
+	}
+	var n, converted int
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w := RuneWidth(r, size, from)
+		if n+w > column {
+			return converted
+		}
+		n += w
+		converted += RuneWidth(r, size, to)
+		i += size
+	}
+	return converted + column - n
+}
+
+// ByteOffset returns the byte offset of the UTF-16 column on the line s,
+// which is at most the length of s.
+func ByteOffset(s string, column int) int {
+	if i := ConvertColumn(s, column, UTF16, Bytes); i < len(s) {
+		return i
+	}
+	return len(s)
+}
+
+// UTF16Len returns the length of s in UTF-16 code units.
+func UTF16Len(s string) int {
+	return ConvertColumn(s, len(s), Bytes, UTF16)
+}
+
+// RuneWidth returns the width of the rune in the unit.
+// The size of the rune in bytes is only used for Bytes.
+func RuneWidth(r rune, size int, unit Unit) int {
+	switch unit {
+	case Bytes:
+		return size
+	case Runes:
+		return 1
+	default:
+		if r >= 0x10000 {
+			return 2
+		}
+		return 1
+	}
+}
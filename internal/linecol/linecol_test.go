@@ -0,0 +1,46 @@
+package linecol_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap/internal/linecol"
+)
+
+func TestIndex(t *testing.T) {
+	text := "a\r\nb\rc\n\nd"
+	ix := linecol.NewIndex(text)
+	for n, want := range []string{"a", "b", "c", "", "d"} {
+		got, ok := ix.Line(text, n+1)
+		if !ok || got != want {
+			t.Errorf("line %d: got %q, %v, wanted %q", n+1, got, ok, want)
+		}
+		if got, ok := linecol.Line(text, n+1); !ok || got != want {
+			t.Errorf("Line %d: got %q, %v, wanted %q", n+1, got, ok, want)
+		}
+	}
+	if _, ok := ix.Line(text, 6); ok {
+		t.Error("line 6 must not exist")
+	}
+	if _, ok := ix.Line(text, 0); ok {
+		t.Error("line 0 must not exist")
+	}
+
+	want := []string{"a", "b", "c", "", "d"}
+	if got := linecol.Split(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Split: got %q, wanted %q", got, want)
+	}
+}
+
+func TestByteOffset(t *testing.T) {
+	// "é" is 2 bytes and 1 UTF-16 unit, "😀" is 4 bytes and 2 UTF-16 units.
+	s := `"é😀"`
+	if n := linecol.UTF16Len(s); n != 5 {
+		t.Errorf("UTF16Len: got %d, wanted 5", n)
+	}
+	for column, want := range []int{0, 1, 3, 3, 7, 8, 8} {
+		if got := linecol.ByteOffset(s, column); got != want {
+			t.Errorf("ByteOffset(%d): got %d, wanted %d", column, got, want)
+		}
+	}
+}
@@ -9,4 +9,10 @@ import "encoding/json"
 // This version uses the standard encoding/json package
 func unmarshalJSON(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
-}
\ No newline at end of file
+}
+
+// marshalJSON is the JSON marshaling function
+// This version uses the standard encoding/json package
+func marshalJSON(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
@@ -10,4 +10,10 @@ import "encoding/json/v2"
 // Build with: GOEXPERIMENT=jsonv2 go build -tags=jsonv2 ./...
 func unmarshalJSON(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
-}
\ No newline at end of file
+}
+
+// marshalJSON is the JSON marshaling function
+// This version uses the experimental json/v2 package for better performance
+func marshalJSON(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
@@ -0,0 +1,224 @@
+package sourcemap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ContentLoader loads the content of sources that is not embedded
+// in the source map.
+type ContentLoader interface {
+	// LoadContent returns the content of the source. The source is
+	// already resolved against the source root and the source map URL,
+	// exactly as Consumer.Source returns it.
+	LoadContent(source string) (string, error)
+}
+
+// ContentLoaderFunc is an adapter to use a function as a ContentLoader.
+type ContentLoaderFunc func(source string) (string, error)
+
+func (fn ContentLoaderFunc) LoadContent(source string) (string, error) {
+	return fn(source)
+}
+
+// DirLoader loads sources from the local filesystem. Relative sources
+// are read from Dir and must not leave it, as the sources come from the
+// map, which may be untrusted. Absolute paths and file URLs are rejected
+// unless Trusted is set.
+type DirLoader struct {
+	Dir string
+	// Trusted allows absolute paths and file URLs, which are read as is,
+	// and relative sources outside of Dir. Set it only for maps from
+	// a trusted origin.
+	Trusted bool
+}
+
+func (l DirLoader) LoadContent(source string) (string, error) {
+	name := source
+	if u, err := url.Parse(source); err == nil && u.IsAbs() {
+		if u.Scheme != "file" {
+			return "", fmt.Errorf("sourcemap: can't load %q from the filesystem", source)
+		}
+		name = u.Path
+	}
+
+	if l.Trusted {
+		name = filepath.FromSlash(name)
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(l.Dir, name)
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	name = path.Clean(filepath.ToSlash(name))
+	if !fs.ValidPath(name) || filepath.IsAbs(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("sourcemap: source %q is outside of the directory", source)
+	}
+	dir := l.Dir
+	if dir == "" {
+		dir = "."
+	}
+	b, err := fs.ReadFile(os.DirFS(dir), name)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// FSLoader loads sources from FS. A source is looked up by its path
+// with the scheme, the host and the leading slash removed, so both
+// "/src/app.js" and "https://example.com/src/app.js" are read from "src/app.js".
+type FSLoader struct {
+	FS fs.FS
+}
+
+func (l FSLoader) LoadContent(source string) (string, error) {
+	name := source
+	if u, err := url.Parse(source); err == nil {
+		name = u.Path
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	b, err := fs.ReadFile(l.FS, name)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DefaultMaxContentSize is the size limit of the sources fetched by
+// HTTPLoader if MaxSize is not set.
+const DefaultMaxContentSize = 10 << 20
+
+// HTTPLoader fetches http and https sources. The map names the URLs, so
+// for maps that may be untrusted, Hosts must list the hosts that can be
+// fetched.
+type HTTPLoader struct {
+	// Client is used to fetch the sources.
+	// If nil, http.DefaultClient is used.
+	Client *http.Client
+	// Hosts are the hosts, with the port if it is not the default one,
+	// whose sources are fetched. If empty, all hosts are allowed.
+	Hosts []string
+	// MaxSize is the size limit of a source in bytes.
+	// If zero, DefaultMaxContentSize is used.
+	MaxSize int64
+}
+
+func (l HTTPLoader) LoadContent(source string) (string, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("sourcemap: can't fetch %q over HTTP", source)
+	}
+	if len(l.Hosts) > 0 && !l.allowed(u.Host) {
+		return "", fmt.Errorf("sourcemap: host of %q is not allowed", source)
+	}
+
+	resp, err := l.client().Get(source)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("sourcemap: got %s fetching %q", resp.Status, source)
+	}
+
+	maxSize := l.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxContentSize
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(b)) > maxSize {
+		return "", fmt.Errorf("sourcemap: %q is larger than %d bytes", source, maxSize)
+	}
+	return string(b), nil
+}
+
+// client returns the client to use. If Hosts is set, it is a copy of
+// Client that checks every redirect against Hosts too.
+func (l HTTPLoader) client() *http.Client {
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if len(l.Hosts) == 0 {
+		return client
+	}
+
+	c := *client
+	checkRedirect := client.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !l.allowed(req.URL.Host) {
+			return fmt.Errorf("sourcemap: redirect to host of %q is not allowed", req.URL)
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &c
+}
+
+func (l HTTPLoader) allowed(host string) bool {
+	for _, h := range l.Hosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadContent loads the source content using the configured loader.
+// Loaded content is cached, failures are not, so they are retried.
+func (c *Consumer) loadContent(source string) (string, error) {
+	if c.loader == nil {
+		return "", errNoContent(source)
+	}
+
+	c.mu.Lock()
+	content, ok := c.loaded[source]
+	c.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+
+	content, err := c.loader.LoadContent(source)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if c.loaded == nil {
+		c.loaded = make(map[string]string)
+	}
+	c.loaded[source] = content
+	c.mu.Unlock()
+
+	return content, nil
+}
+
+func errNoContent(source string) error {
+	return fmt.Errorf("sourcemap: content of source=%q is not available: %w", source, fs.ErrNotExist)
+}
@@ -0,0 +1,216 @@
+package sourcemap_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestDirLoader(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "one.js")
+	if err := os.WriteFile(name, []byte(oneSourceContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := sourcemap.DirLoader{Dir: dir}
+	for _, source := range []string{"one.js", "./one.js", "sub/../one.js"} {
+		content, err := loader.LoadContent(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content != oneSourceContent {
+			t.Fatalf("%s: got %q", source, content)
+		}
+	}
+
+	outside := []string{
+		name,
+		"file://" + filepath.ToSlash(name),
+		"../one.js",
+		"sub/../../one.js",
+		"http://the/root/one.js",
+	}
+	for _, source := range outside {
+		if _, err := (sourcemap.DirLoader{Dir: sub}).LoadContent(source); err == nil {
+			t.Fatalf("%s: expected an error for a source outside of the directory", source)
+		}
+	}
+
+	trusted := sourcemap.DirLoader{Dir: sub, Trusted: true}
+	for _, source := range outside[:4] {
+		content, err := trusted.LoadContent(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content != oneSourceContent {
+			t.Fatalf("%s: got %q", source, content)
+		}
+	}
+	if _, err := trusted.LoadContent("http://the/root/one.js"); err == nil {
+		t.Fatal("expected an error for an http source")
+	}
+}
+
+func TestFSLoader(t *testing.T) {
+	loader := sourcemap.FSLoader{FS: fstest.MapFS{
+		"the/root/one.js": {Data: []byte(oneSourceContent)},
+	}}
+	for _, source := range []string{"/the/root/one.js", "http://example.com/the/root/one.js", "the/root/one.js"} {
+		content, err := loader.LoadContent(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content != oneSourceContent {
+			t.Fatalf("%s: got %q", source, content)
+		}
+	}
+
+	if _, err := loader.LoadContent("/the/root/two.js"); err == nil {
+		t.Fatal("expected an error for a missing source")
+	}
+}
+
+func TestHTTPLoader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/the/root/one.js" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(oneSourceContent))
+	}))
+	defer srv.Close()
+
+	loader := sourcemap.HTTPLoader{Client: srv.Client()}
+	content, err := loader.LoadContent(srv.URL + "/the/root/one.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != oneSourceContent {
+		t.Fatalf("got %q", content)
+	}
+
+	if _, err := loader.LoadContent(srv.URL + "/the/root/two.js"); err == nil {
+		t.Fatal("expected an error for a missing source")
+	}
+	if _, err := loader.LoadContent("/the/root/one.js"); err == nil {
+		t.Fatal("expected an error for a path")
+	}
+
+	limited := sourcemap.HTTPLoader{Client: srv.Client(), MaxSize: int64(len(oneSourceContent)) - 1}
+	if _, err := limited.LoadContent(srv.URL + "/the/root/one.js"); err == nil {
+		t.Fatal("expected an error for a source over the size limit")
+	}
+
+	allowed := sourcemap.HTTPLoader{Client: srv.Client(), Hosts: []string{"example.com"}}
+	if _, err := allowed.LoadContent(srv.URL + "/the/root/one.js"); err == nil {
+		t.Fatal("expected an error for a host that is not allowed")
+	}
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed.Hosts = append(allowed.Hosts, u.Host)
+	if _, err := allowed.LoadContent(srv.URL + "/the/root/one.js"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPLoaderRedirect(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer other.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/away.js":
+			http.Redirect(w, r, other.URL+"/secret.js", http.StatusFound)
+		case "/moved.js":
+			http.Redirect(w, r, "/one.js", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte(oneSourceContent))
+		}
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader := sourcemap.HTTPLoader{Client: srv.Client(), Hosts: []string{u.Host}}
+	if content, err := loader.LoadContent(srv.URL + "/away.js"); err == nil {
+		t.Fatalf("got %q, expected an error for a redirect to a host that is not allowed", content)
+	}
+	content, err := loader.LoadContent(srv.URL + "/moved.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != oneSourceContent {
+		t.Fatalf("got %q, wanted %q", content, oneSourceContent)
+	}
+	if srv.Client().CheckRedirect != nil {
+		t.Fatal("the client of the loader is modified")
+	}
+}
+
+func TestWithContentLoader(t *testing.T) {
+	jsonStr := strings.Replace(sourceMapJSON,
+		`"sourcesContent": `+j([]string{oneSourceContent, twoSourceContent}),
+		`"sourcesContent": [null]`, 1)
+
+	var calls int
+	loader := sourcemap.ContentLoaderFunc(func(source string) (string, error) {
+		calls++
+		if source == "/the/root/two.js" {
+			return twoSourceContent, nil
+		}
+		return "", os.ErrNotExist
+	})
+
+	smap, err := sourcemap.Parse("", []byte(jsonStr), sourcemap.WithContentLoader(loader))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if content := smap.SourceContent("/the/root/two.js"); content != twoSourceContent {
+			t.Fatalf("got %q", content)
+		}
+		if content := smap.SourceContent("/the/root/one.js"); content != "" {
+			t.Fatalf("got %q", content)
+		}
+	}
+	// Loaded content is cached and failures are retried.
+	if calls != 3 {
+		t.Fatalf("loader called %d times, wanted 3", calls)
+	}
+
+	if _, err := smap.LoadSourceContent("/the/root/one.js"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, wanted the error of the loader", err)
+	}
+	if _, err := smap.LoadSourceContent("/the/root/three.js"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, wanted an error for an unknown source", err)
+	}
+	if calls != 4 {
+		t.Fatal("loader must not be called for unknown sources")
+	}
+
+	_, context, _, ok := smap.SourceLines("/the/root/two.js", 2, 0, 0)
+	if !ok || context != "  return n + 1;" {
+		t.Fatalf("got %q %v", context, ok)
+	}
+}
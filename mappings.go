@@ -1,11 +1,12 @@
 package sourcemap
 
 import (
-	"errors"
+	"fmt"
 	"io"
+	"sort"
 	"strings"
 
-	"github.com/go-sourcemap/sourcemap/internal/base64vlq"
+	"github.com/go-sourcemap/sourcemap/base64vlq"
 )
 
 type fn func(m *mappings) (fn, error)
@@ -17,28 +18,34 @@ type mapping struct {
 	sourceLine   int32
 	sourceColumn int32
 	namesInd     int32
+	// isRange reports whether the segment maps the following generated
+	// positions 1:1 to the original positions that follow its own.
+	isRange bool
 }
 
 type mappings struct {
-	rd  *strings.Reader
-	dec base64vlq.Decoder
+	s string
+	i int
 
-	hasValue bool
-	hasName  bool
-	value    mapping
+	fields int
+	value  mapping
 
 	values []mapping
 }
 
 func parseMappings(s string) ([]mapping, error) {
-	if s == "" {
-		return nil, errors.New("sourcemap: mappings are empty")
+	values, err := decodeMappings(s)
+	if err != nil {
+		return nil, err
 	}
+	sortMappings(values)
+	return values, nil
+}
 
-	rd := strings.NewReader(s)
+// decodeMappings returns the mappings in the order of the segments.
+func decodeMappings(s string) ([]mapping, error) {
 	m := &mappings{
-		rd:  rd,
-		dec: base64vlq.NewDecoder(rd),
+		s: s,
 
 		values: make([]mapping, 0, mappingsNumber(s)),
 	}
@@ -50,9 +57,21 @@ func parseMappings(s string) ([]mapping, error) {
 		return nil, err
 	}
 
-	values := m.values
-	m.values = nil
-	return values, nil
+	return m.values, nil
+}
+
+// sortMappings sorts the mappings in generated order,
+// as the segments of a line may be out of order.
+func sortMappings(values []mapping) {
+	less := func(i, j int) bool {
+		if values[i].genLine == values[j].genLine {
+			return values[i].genColumn < values[j].genColumn
+		}
+		return values[i].genLine < values[j].genLine
+	}
+	if !sort.SliceIsSorted(values, less) {
+		sort.SliceStable(values, less)
+	}
 }
 
 func mappingsNumber(s string) int {
@@ -61,44 +80,47 @@ func mappingsNumber(s string) int {
 
 func (m *mappings) parse() error {
 	next := parseGenCol
-	for {
-		c, err := m.rd.ReadByte()
-		if err == io.EOF {
-			m.pushValue()
-			return nil
-		}
-		if err != nil {
-			return err
-		}
-
-		switch c {
+	for m.i < len(m.s) {
+		switch m.s[m.i] {
 		case ',':
-			m.pushValue()
+			m.i++
+			if err := m.pushValue(); err != nil {
+				return err
+			}
 			next = parseGenCol
 		case ';':
-			m.pushValue()
+			m.i++
+			if err := m.pushValue(); err != nil {
+				return err
+			}
 
 			m.value.genLine++
 			m.value.genColumn = 0
 
 			next = parseGenCol
 		default:
-			err := m.rd.UnreadByte()
-			if err != nil {
-				return err
-			}
-
+			var err error
 			next, err = next(m)
 			if err != nil {
 				return err
 			}
-			m.hasValue = true
+			m.fields++
 		}
 	}
+	return m.pushValue()
+}
+
+func (m *mappings) decode() (int32, error) {
+	n, next, err := base64vlq.DecodeString(m.s, m.i)
+	if err != nil {
+		return 0, fmt.Errorf("sourcemap: mappings at offset=%d: %w", next, err)
+	}
+	m.i = next
+	return n, nil
 }
 
 func parseGenCol(m *mappings) (fn, error) {
-	n, err := m.dec.Decode()
+	n, err := m.decode()
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +129,7 @@ func parseGenCol(m *mappings) (fn, error) {
 }
 
 func parseSourcesInd(m *mappings) (fn, error) {
-	n, err := m.dec.Decode()
+	n, err := m.decode()
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +138,7 @@ func parseSourcesInd(m *mappings) (fn, error) {
 }
 
 func parseSourceLine(m *mappings) (fn, error) {
-	n, err := m.dec.Decode()
+	n, err := m.decode()
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +147,7 @@ func parseSourceLine(m *mappings) (fn, error) {
 }
 
 func parseSourceCol(m *mappings) (fn, error) {
-	n, err := m.dec.Decode()
+	n, err := m.decode()
 	if err != nil {
 		return nil, err
 	}
@@ -134,31 +156,192 @@ func parseSourceCol(m *mappings) (fn, error) {
 }
 
 func parseNamesInd(m *mappings) (fn, error) {
-	n, err := m.dec.Decode()
+	n, err := m.decode()
 	if err != nil {
 		return nil, err
 	}
-	m.hasName = true
 	m.value.namesInd += n
 	return parseGenCol, nil
 }
 
-func (m *mappings) pushValue() {
-	if !m.hasValue {
+func (m *mappings) pushValue() error {
+	fields := m.fields
+	m.fields = 0
+
+	v := m.value
+	switch fields {
+	case 0:
+		return nil
+	case 1:
+		v = mapping{
+			genLine:    v.genLine,
+			genColumn:  v.genColumn,
+			sourcesInd: -1,
+			namesInd:   -1,
+		}
+	case 4:
+		v.namesInd = -1
+	case 5:
+	default:
+		return fmt.Errorf(
+			"sourcemap: mapping segment at line=%d has %d fields", v.genLine, fields)
+	}
+
+	if v.genColumn < 0 ||
+		fields >= 4 && (v.sourcesInd < 0 || v.sourceLine < 1 || v.sourceColumn < 0) ||
+		fields == 5 && v.namesInd < 0 {
+		return fmt.Errorf(
+			"sourcemap: mapping segment at line=%d has a negative value", v.genLine)
+	}
+
+	m.values = append(m.values, v)
+	return nil
+}
+
+// encodeMappings encodes mappings sorted in generated order.
+func encodeMappings(mappings []mapping) string {
+	var b strings.Builder
+	e := newMappingsEncoder(&b)
+	for i := range mappings {
+		e.encode(&mappings[i])
+	}
+	return b.String()
+}
+
+// mappingsEncoder encodes the segments of mappings
+// that are added in generated order.
+type mappingsEncoder struct {
+	w    io.ByteWriter
+	enc  *base64vlq.Encoder
+	prev mapping
+	// empty reports whether no segment has been written.
+	empty bool
+}
+
+func newMappingsEncoder(w io.ByteWriter) *mappingsEncoder {
+	return &mappingsEncoder{
+		w:   w,
+		enc: base64vlq.NewEncoder(w),
+		prev: mapping{
+			genLine:    1,
+			sourceLine: 1,
+		},
+		empty: true,
+	}
+}
+
+// encode writes the segment of the mapping. Errors of the writer
+// are left to the caller, as the writers are buffers.
+func (e *mappingsEncoder) encode(m *mapping) {
+	prev := &e.prev
+	if m.genLine != prev.genLine {
+		for ; prev.genLine < m.genLine; prev.genLine++ {
+			_ = e.w.WriteByte(';')
+		}
+		prev.genColumn = 0
+	} else if !e.empty {
+		_ = e.w.WriteByte(',')
+	}
+	e.empty = false
+
+	_ = e.enc.Encode(m.genColumn - prev.genColumn)
+	prev.genColumn = m.genColumn
+
+	if m.sourcesInd < 0 {
 		return
 	}
-	m.hasValue = false
-	if m.hasName {
-		m.values = append(m.values, m.value)
-		m.hasName = false
-	} else {
-		m.values = append(m.values, mapping{
-			genLine:      m.value.genLine,
-			genColumn:    m.value.genColumn,
-			sourcesInd:   m.value.sourcesInd,
-			sourceLine:   m.value.sourceLine,
-			sourceColumn: m.value.sourceColumn,
-			namesInd:     -1,
-		})
+	_ = e.enc.Encode(m.sourcesInd - prev.sourcesInd)
+	_ = e.enc.Encode(m.sourceLine - prev.sourceLine)
+	_ = e.enc.Encode(m.sourceColumn - prev.sourceColumn)
+	prev.sourcesInd = m.sourcesInd
+	prev.sourceLine = m.sourceLine
+	prev.sourceColumn = m.sourceColumn
+
+	if m.namesInd < 0 {
+		return
+	}
+	_ = e.enc.Encode(m.namesInd - prev.namesInd)
+	prev.namesInd = m.namesInd
+}
+
+// markRangeMappings marks the range mappings listed by the rangeMappings
+// field, which holds the indices of the range mappings of every line
+// of the mappings in the order of the segments. Lines are separated by
+// ";" and every index is encoded relative to the previous one on the
+// line, starting at -1, so that all values are positive.
+func markRangeMappings(values []mapping, s string) error {
+	j := 0
+	for i, line := range strings.Split(s, ";") {
+		genLine := int32(i + 1)
+		for j < len(values) && values[j].genLine < genLine {
+			j++
+		}
+		n := 0
+		for j+n < len(values) && values[j+n].genLine == genLine {
+			n++
+		}
+
+		idx := -1
+		for k := 0; k < len(line); {
+			delta, next, err := base64vlq.DecodeUnsignedString(line, k)
+			if err != nil {
+				return err
+			}
+			k = next
+			if delta == 0 || idx+int(delta) >= n {
+				return fmt.Errorf(
+					"sourcemap: range mapping at line=%d is out of range", genLine)
+			}
+			idx += int(delta)
+			values[j+idx].isRange = true
+		}
+	}
+	return nil
+}
+
+// encodeRangeMappings encodes the rangeMappings field
+// of mappings sorted in generated order.
+func encodeRangeMappings(mappings []mapping) string {
+	var b strings.Builder
+	e := newRangesEncoder(&b)
+	for i := range mappings {
+		e.encode(&mappings[i])
+	}
+	return b.String()
+}
+
+// rangesEncoder encodes the rangeMappings field
+// of mappings that are added in generated order.
+type rangesEncoder struct {
+	w   io.ByteWriter
+	enc *base64vlq.Encoder
+	// line is the last line written.
+	line int32
+	// genLine is the line of the last mapping and idx is the index
+	// of the next mapping on the line.
+	genLine   int32
+	idx, prev int
+}
+
+func newRangesEncoder(w io.ByteWriter) *rangesEncoder {
+	return &rangesEncoder{
+		w:    w,
+		enc:  base64vlq.NewEncoder(w),
+		line: 1,
+	}
+}
+
+func (e *rangesEncoder) encode(m *mapping) {
+	if m.genLine != e.genLine {
+		e.genLine = m.genLine
+		e.idx, e.prev = 0, -1
+	}
+	if m.isRange {
+		for ; e.line < m.genLine; e.line++ {
+			_ = e.w.WriteByte(';')
+		}
+		_ = e.enc.EncodeUnsigned(uint32(e.idx - e.prev))
+		e.prev = e.idx
 	}
+	e.idx++
 }
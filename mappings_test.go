@@ -12,9 +12,14 @@ func TestParseMappings(t *testing.T) {
 			{genLine: 7, genColumn: 18, sourceLine: 3, sourceColumn: 15, namesInd: -1},
 			{genLine: 7, genColumn: 30, sourceLine: 3, sourceColumn: 27, namesInd: -1},
 			{genLine: 7, genColumn: 31, sourceLine: 4, sourceColumn: 1, namesInd: -1},
-			{genLine: 7, genColumn: 32, sourceLine: 4, sourceColumn: 1, namesInd: -1},
+			{genLine: 7, genColumn: 32, sourcesInd: -1, namesInd: -1},
 			{genLine: 9, genColumn: 0, sourceLine: 1, sourceColumn: 0, namesInd: -1},
 		},
+		"": {},
+		"CAAA,DAAA": {
+			{genLine: 1, genColumn: 0, sourceLine: 1, sourceColumn: 0, namesInd: -1},
+			{genLine: 1, genColumn: 1, sourceLine: 1, sourceColumn: 0, namesInd: -1},
+		},
 	}
 	for k, c := range cases {
 		k, c := k, c
@@ -30,3 +35,103 @@ func TestParseMappings(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMappingsError(t *testing.T) {
+	t.Parallel()
+	for _, s := range []string{
+		"AA",
+		"AAA",
+		"AAAAAA",
+		"D",
+		"ADAA",
+		"AADA",
+		"AAAD",
+		"AAAAD",
+	} {
+		if _, err := parseMappings(s); err == nil {
+			t.Errorf("%q: got no error", s)
+		}
+	}
+}
+
+func TestEncodeMappings(t *testing.T) {
+	t.Parallel()
+	for _, s := range []string{
+		"",
+		"AAAA",
+		";;;;;;kBAEe,YAAY,CAC1B,C;;AAHD",
+		"AAAA,CAAC,E;;ACAAA,GCCCC",
+	} {
+		v, err := parseMappings(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := encodeMappings(v); got != s {
+			t.Errorf("got %q, wanted %q", got, s)
+		}
+	}
+}
+
+func TestRangeMappings(t *testing.T) {
+	t.Parallel()
+	// The segments of the first line are out of order
+	// and the range mapping is the second one.
+	v, err := decodeMappings("EAAA,DAAA;;AAAA,CAAA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := markRangeMappings(v, "C;;BB"); err != nil {
+		t.Fatal(err)
+	}
+	sortMappings(v)
+
+	var ranges []int32
+	for _, m := range v {
+		if m.isRange {
+			ranges = append(ranges, m.genLine*10+m.genColumn)
+		}
+	}
+	if !reflect.DeepEqual(ranges, []int32{11, 30, 31}) {
+		t.Fatalf("got range mappings at %v", ranges)
+	}
+	if got := encodeRangeMappings(v); got != "B;;BB" {
+		t.Errorf("got %q, wanted %q", got, "B;;BB")
+	}
+
+	for _, s := range []string{"A", "D", "BB;B", ";B"} {
+		v, _ := decodeMappings("AAAA,CAAA;")
+		if err := markRangeMappings(v, s); err == nil {
+			t.Errorf("%q: got no error", s)
+		}
+	}
+}
+
+func FuzzParseMappings(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"AAAA",
+		"AAAAA,CAAC",
+		";;;;;;kBAEe,YAAY,CAC1B,C;;AAHD",
+		"A,C;E,,G;",
+		"+/////D",
+		"gggggggggggggggggg",
+	} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		v, err := parseMappings(s)
+		if err != nil {
+			return
+		}
+
+		encoded := encodeMappings(v)
+		v2, err := parseMappings(encoded)
+		if err != nil {
+			t.Fatalf("%q: can't parse the encoded mappings %q: %s", s, encoded, err)
+		}
+		if !reflect.DeepEqual(v, v2) {
+			t.Fatalf("%q: got %v after encoding to %q, wanted %v", s, v2, encoded, v)
+		}
+	})
+}
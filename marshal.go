@@ -0,0 +1,110 @@
+package sourcemap
+
+import "encoding/json"
+
+type jsonSourceMap struct {
+	Version        int               `json:"version"`
+	File           string            `json:"file,omitempty"`
+	Sources        []*string         `json:"sources"`
+	SourcesContent []*string         `json:"sourcesContent,omitempty"`
+	Names          []json.RawMessage `json:"names"`
+	Mappings       string            `json:"mappings"`
+	RangeMappings  string            `json:"rangeMappings,omitempty"`
+	IgnoreList     []int             `json:"ignoreList,omitempty"`
+	DebugID        string            `json:"debugId,omitempty"`
+	Scopes         string            `json:"scopes,omitempty"`
+
+	extensions map[string]json.RawMessage
+}
+
+func (m *jsonSourceMap) MarshalJSON() ([]byte, error) {
+	type plain jsonSourceMap
+	b, err := marshalJSON((*plain)(m))
+	if err != nil {
+		return nil, err
+	}
+	return appendExtensions(b, m.extensions)
+}
+
+type jsonOffset struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type jsonSection struct {
+	Offset jsonOffset     `json:"offset"`
+	Map    *jsonSourceMap `json:"map"`
+}
+
+type jsonIndexMap struct {
+	Version  int           `json:"version"`
+	File     string        `json:"file,omitempty"`
+	DebugID  string        `json:"debugId,omitempty"`
+	Sections []jsonSection `json:"sections"`
+
+	extensions map[string]json.RawMessage
+}
+
+func (m *jsonIndexMap) MarshalJSON() ([]byte, error) {
+	type plain jsonIndexMap
+	b, err := marshalJSON((*plain)(m))
+	if err != nil {
+		return nil, err
+	}
+	return appendExtensions(b, m.extensions)
+}
+
+// MarshalJSON encodes the map as a version 3 source map. Sources are
+// written as resolved, so the source root is omitted. Vendor extension
+// fields are written as they were read. Parsing the result
+// with RawResolver yields an equivalent Consumer.
+func (c *Consumer) MarshalJSON() ([]byte, error) {
+	if !c.indexed && len(c.sections) == 1 {
+		return marshalJSON(newJSONSourceMap(c.sections[0].Map))
+	}
+
+	v := jsonIndexMap{
+		Version:  3,
+		File:     c.file,
+		DebugID:  c.debugID,
+		Sections: make([]jsonSection, 0, len(c.sections)),
+
+		extensions: c.top.extensions,
+	}
+	// Sections are stored in reverse order.
+	for i := len(c.sections) - 1; i >= 0; i-- {
+		s := &c.sections[i]
+		v.Sections = append(v.Sections, jsonSection{
+			Offset: jsonOffset{
+				Line:   s.Offset.Line,
+				Column: s.Offset.Column,
+			},
+			Map: newJSONSourceMap(s.Map),
+		})
+	}
+	return marshalJSON(&v)
+}
+
+func newJSONSourceMap(m *sourceMap) *jsonSourceMap {
+	v := &jsonSourceMap{
+		Version:        3,
+		File:           m.File,
+		Sources:        m.Sources,
+		SourcesContent: m.SourcesContent,
+		Names:          m.Names,
+		Mappings:       encodeMappings(m.mappings),
+		RangeMappings:  encodeRangeMappings(m.mappings),
+		IgnoreList:     m.IgnoreList,
+		DebugID:        m.DebugID,
+		Scopes:         m.Scopes,
+
+		extensions: m.extensions,
+	}
+	if v.Sources == nil {
+		v.Sources = []*string{}
+	}
+	if v.Names == nil {
+		v.Names = []json.RawMessage{}
+	}
+	return v
+}
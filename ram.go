@@ -0,0 +1,21 @@
+package sourcemap
+
+// IsRAMBundle reports whether the map is the map of a Metro RAM bundle,
+// which has the line offsets of its modules in the x_facebook_offsets field.
+func (c *Consumer) IsRAMBundle() bool {
+	return c.top.XFacebookOffsets != nil
+}
+
+// SourceForModule is like Source, but genLine is the line in the code
+// of the module of a RAM bundle, as in the frames of RAM bundles, whose
+// files are named after the modules. The line is moved by the offset of
+// the module in the x_facebook_offsets field of the map.
+func (c *Consumer) SourceForModule(
+	moduleID, genLine, genColumn int,
+) (source, name string, line, column int, ok bool) {
+	offsets := c.top.XFacebookOffsets
+	if moduleID < 0 || moduleID >= len(offsets) || offsets[moduleID] == nil {
+		return
+	}
+	return c.Source(*offsets[moduleID]+genLine, genColumn)
+}
@@ -0,0 +1,47 @@
+package sourcemap_test
+
+import (
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestSourceForModule(t *testing.T) {
+	jsonStr := `{
+  "version": 3,
+  "sections": [
+    {"offset": {"line": 0, "column": 0}, "map": {"version": 3, "sources": ["startup.js"], "names": [], "mappings": "AAAA"}},
+    {"offset": {"line": 3, "column": 0}, "map": {"version": 3, "sources": ["five.js"], "names": [], "mappings": ";AAAA,EAAE"}}
+  ],
+  "x_facebook_offsets": [0, null, null, null, null, 3]
+}`
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !smap.IsRAMBundle() {
+		t.Fatal("the map must be a RAM bundle map")
+	}
+
+	source, _, line, column, ok := smap.SourceForModule(5, 2, 2)
+	if !ok || source != "five.js" || line != 1 || column != 2 {
+		t.Errorf("got %s:%d:%d, %v, wanted five.js:1:2", source, line, column, ok)
+	}
+	source, _, line, column, ok = smap.SourceForModule(0, 1, 0)
+	if !ok || source != "startup.js" || line != 1 || column != 0 {
+		t.Errorf("got %s:%d:%d, %v, wanted startup.js:1:0", source, line, column, ok)
+	}
+	for _, id := range []int{-1, 1, 6} {
+		if _, _, _, _, ok := smap.SourceForModule(id, 1, 0); ok {
+			t.Errorf("module=%d must not exist", id)
+		}
+	}
+
+	smap, err = sourcemap.Parse("", []byte(sourceMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if smap.IsRAMBundle() {
+		t.Error("the map must not be a RAM bundle map")
+	}
+}
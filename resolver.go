@@ -0,0 +1,170 @@
+package sourcemap
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// SourcePathResolver resolves the entries of the sources array
+// to the sources reported by the Consumer.
+type SourcePathResolver interface {
+	// ResolveSource returns the source for the entry of the sources array
+	// of the map located at sourcemapURL with the given source root.
+	ResolveSource(sourcemapURL, sourceRoot, source string) (string, error)
+}
+
+// SourcePathResolverFunc is an adapter to use a function
+// as a SourcePathResolver. It can wrap one of the built-in resolvers,
+// for example to rewrite sources to repository-relative paths.
+type SourcePathResolverFunc func(sourcemapURL, sourceRoot, source string) (string, error)
+
+func (fn SourcePathResolverFunc) ResolveSource(
+	sourcemapURL, sourceRoot, source string,
+) (string, error) {
+	return fn(sourcemapURL, sourceRoot, source)
+}
+
+var (
+	// LegacyResolver is the resolver used by default in earlier versions.
+	// It joins sources with an absolute source root URL or,
+	// without a source root, with the directory of an absolute source map URL.
+	// A relative source root is joined as a path. Sources that are absolute
+	// paths or URLs are returned as is. Paths are cleaned, so ".." segments
+	// are collapsed and double slashes are removed.
+	LegacyResolver SourcePathResolver = SourcePathResolverFunc(resolveLegacy)
+
+	// URLResolver resolves sources as specified by ECMA-426: the source root
+	// followed by a slash, unless it already ends with one, is prepended to
+	// the source, which is then resolved as a URL against the source map URL.
+	// An empty source root is treated as absent. If the source map URL is not
+	// an absolute URL, which the specification does not cover, relative
+	// sources are resolved as paths against the directory of the source map.
+	// Sources that are not valid URLs, such as "100%.css", are returned as is.
+	URLResolver SourcePathResolver = SourcePathResolverFunc(resolveURL)
+
+	// RawResolver returns sources exactly as they appear in the map.
+	RawResolver SourcePathResolver = SourcePathResolverFunc(resolveRaw)
+
+	// WebpackResolver turns the sources emitted by webpack and Vite into
+	// project-relative paths: "webpack:///./src/app.js" and
+	// "webpack://namespace/./src/app.js" become "src/app.js" and
+	// "/@fs/home/app/src/app.js" becomes "/home/app/src/app.js".
+	// Other sources are resolved by URLResolver.
+	WebpackResolver SourcePathResolver = SourcePathResolverFunc(resolveWebpack)
+)
+
+// StripPrefixResolver returns a resolver that removes the first matching
+// prefix from the sources and otherwise returns them as they appear in the map.
+func StripPrefixResolver(prefixes ...string) SourcePathResolver {
+	return SourcePathResolverFunc(func(_, _, source string) (string, error) {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(source, prefix) {
+				return source[len(prefix):], nil
+			}
+		}
+		return source, nil
+	})
+}
+
+// WithSourcePathResolver sets the resolver for the sources of the map.
+// URLResolver is used by default. Pass LegacyResolver to keep the behavior
+// of earlier versions, which differs for relative source roots and for
+// sources with a leading slash, query or fragment.
+func WithSourcePathResolver(resolver SourcePathResolver) Option {
+	return func(c *Consumer) {
+		c.resolver = resolver
+	}
+}
+
+func resolveLegacy(sourcemapURL, sourceRoot, source string) (string, error) {
+	var root *url.URL
+	if sourceRoot != "" {
+		u, err := url.Parse(sourceRoot)
+		if err != nil {
+			return "", err
+		}
+		if u.IsAbs() {
+			root = u
+		}
+	} else if sourcemapURL != "" {
+		u, err := url.Parse(sourcemapURL)
+		if err != nil {
+			return "", err
+		}
+		if u.IsAbs() {
+			u.Path = path.Dir(u.Path)
+			root = u
+		}
+	}
+
+	if path.IsAbs(source) {
+		return source, nil
+	}
+
+	if u, err := url.Parse(source); err == nil && u.IsAbs() {
+		return source, nil
+	}
+
+	if root != nil {
+		u := *root
+		u.Path = path.Join(u.Path, source)
+		return u.String(), nil
+	}
+
+	if sourceRoot != "" {
+		return path.Join(sourceRoot, source), nil
+	}
+
+	return source, nil
+}
+
+func resolveURL(sourcemapURL, sourceRoot, source string) (string, error) {
+	if sourceRoot != "" && !strings.HasSuffix(sourceRoot, "/") {
+		sourceRoot += "/"
+	}
+	source = sourceRoot + source
+
+	// A source that is not a valid URL is kept as is, which ECMA-426
+	// allows, so that the other sources of the map can still be used.
+	ref, err := url.Parse(source)
+	if err != nil {
+		return source, nil
+	}
+
+	base, err := url.Parse(sourcemapURL)
+	if err == nil && base.IsAbs() {
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	if ref.IsAbs() {
+		// Remove dot segments.
+		return new(url.URL).ResolveReference(ref).String(), nil
+	}
+	if sourcemapURL == "" || path.IsAbs(source) {
+		return source, nil
+	}
+	return path.Join(path.Dir(sourcemapURL), source), nil
+}
+
+func resolveRaw(_, _, source string) (string, error) {
+	return source, nil
+}
+
+func resolveWebpack(sourcemapURL, sourceRoot, source string) (string, error) {
+	if strings.HasPrefix(source, "webpack://") {
+		// Drop the scheme and the namespace.
+		s := source[len("webpack://"):]
+		if i := strings.IndexByte(s, '/'); i != -1 {
+			s = s[i+1:]
+		}
+		for strings.HasPrefix(s, "./") {
+			s = s[len("./"):]
+		}
+		return s, nil
+	}
+	if strings.HasPrefix(source, "/@fs/") {
+		return source[len("/@fs"):], nil
+	}
+	return resolveURL(sourcemapURL, sourceRoot, source)
+}
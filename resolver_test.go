@@ -0,0 +1,167 @@
+package sourcemap_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+type resolverTest struct {
+	mapURL, sourceRoot, source string
+	wanted                     string
+}
+
+func testResolver(t *testing.T, resolver sourcemap.SourcePathResolver, tests []resolverTest) {
+	t.Helper()
+	for _, test := range tests {
+		got, err := resolver.ResolveSource(test.mapURL, test.sourceRoot, test.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.wanted {
+			t.Errorf("(%q, %q, %q): got %q, wanted %q",
+				test.mapURL, test.sourceRoot, test.source, got, test.wanted)
+		}
+	}
+}
+
+func TestLegacyResolver(t *testing.T) {
+	testResolver(t, sourcemap.LegacyResolver, []resolverTest{
+		{"", "/the/root", "one.js", "/the/root/one.js"},
+		{"", "http://the/root", "../one.js", "http://the/one.js"},
+		{"http://the/root/app.min.map", "", "../one.js", "http://the/one.js"},
+		{"http://path/to/map", "", "/another/root/two.js", "/another/root/two.js"},
+		{"http://path/to/map", "", "webpack:///./src/a.js", "webpack:///./src/a.js"},
+		{"", "", "src/a.js", "src/a.js"},
+	})
+}
+
+func TestURLResolver(t *testing.T) {
+	testResolver(t, sourcemap.URLResolver, []resolverTest{
+		{"", "/the/root", "one.js", "/the/root/one.js"},
+		{"", "/the/root/", "one.js", "/the/root/one.js"},
+		{"", "http://the/root", "../one.js", "http://the/one.js"},
+		{"http://the/root/app.min.map", "", "../one.js", "http://the/one.js"},
+		{"http://cdn/js/app.map", "src", "a.js", "http://cdn/js/src/a.js"},
+		{"http://cdn/js/app.map", "", "/src/a.js?v=1#top", "http://cdn/src/a.js?v=1#top"},
+		{"http://cdn/js/app.map", "", "webpack:///./src/a.js", "webpack:///src/a.js"},
+		{"maps/app.map", "", "../src/a.js", "src/a.js"},
+		{"/var/www/app.map", "", "a.js", "/var/www/a.js"},
+		{"", "", "src/a.js", "src/a.js"},
+	})
+}
+
+func TestURLResolverInvalidURL(t *testing.T) {
+	testResolver(t, sourcemap.URLResolver, []resolverTest{
+		{"http://cdn/js/app.map", "", "a%zz.js", "a%zz.js"},
+		{"http://cdn/js/app.map", "", "http://[::1/a.js", "http://[::1/a.js"},
+		{"http://cdn/js/app.map", "", "webpack:///./src/100%.css", "webpack:///./src/100%.css"},
+	})
+
+	smap, err := sourcemap.Parse("http://cdn/js/app.map", []byte(`{
+		"version": 3,
+		"sources": ["webpack:///./src/100%.css", "src/50%zoom.js", "src/a.js"],
+		"mappings": "AAAA,ECAA,ECAA"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := []string{"webpack:///./src/100%.css", "src/50%zoom.js", "http://cdn/js/src/a.js"}
+	for i, column := range []int{0, 2, 4} {
+		file, _, _, _, ok := smap.Source(1, column)
+		if !ok || file != wanted[i] {
+			t.Errorf("column %d: got %q, %t, wanted %q", column, file, ok, wanted[i])
+		}
+	}
+}
+
+// The cases follow the steps of the "Resolving Sources" section
+// of ECMA-426: the source root with a trailing slash is prepended
+// and the result is parsed as a URL with the source map URL as the base.
+func TestURLResolverSpec(t *testing.T) {
+	const mapURL = "https://example.com/js/app.js.map"
+	testResolver(t, sourcemap.URLResolver, []resolverTest{
+		// No source root: relative to the map.
+		{mapURL, "", "app.js", "https://example.com/js/app.js"},
+		{mapURL, "", "./src/../app.js", "https://example.com/js/app.js"},
+		// A leading slash is relative to the origin of the map.
+		{mapURL, "", "/src/app.js", "https://example.com/src/app.js"},
+		// Scheme-relative sources take the scheme of the map.
+		{mapURL, "", "//cdn.example.com/app.js", "https://cdn.example.com/app.js"},
+		// Query strings and fragments are kept.
+		{mapURL, "", "app.js?v=2#L1", "https://example.com/js/app.js?v=2#L1"},
+		// Absolute sources are kept.
+		{mapURL, "", "webpack://app/src/app.js", "webpack://app/src/app.js"},
+		{mapURL, "", "file:///home/app/src/app.js", "file:///home/app/src/app.js"},
+		// A slash is appended to the source root unless it already has one.
+		{mapURL, "src", "app.js", "https://example.com/js/src/app.js"},
+		{mapURL, "src/", "app.js", "https://example.com/js/src/app.js"},
+		// A relative source root is resolved against the map.
+		{mapURL, "../src", "app.js", "https://example.com/src/app.js"},
+		{mapURL, "/src", "app.js", "https://example.com/src/app.js"},
+		// An absolute source root replaces the map URL.
+		{mapURL, "https://other.example.com/src", "app.js", "https://other.example.com/src/app.js"},
+		// Sources are concatenated with the source root, not resolved against it.
+		{mapURL, "https://other.example.com/src", "/app.js", "https://other.example.com/src//app.js"},
+		{mapURL, "https://other.example.com/src", "https://example.com/app.js",
+			"https://other.example.com/src/https://example.com/app.js"},
+	})
+}
+
+func TestRawResolver(t *testing.T) {
+	testResolver(t, sourcemap.RawResolver, []resolverTest{
+		{"http://cdn/js/app.map", "/the/root", "../one.js", "../one.js"},
+		{"", "", "webpack:///./src/a.js", "webpack:///./src/a.js"},
+	})
+}
+
+func TestStripPrefixResolver(t *testing.T) {
+	testResolver(t, sourcemap.StripPrefixResolver("webpack:///", "../"), []resolverTest{
+		{"http://cdn/js/app.map", "", "webpack:///src/a.js", "src/a.js"},
+		{"http://cdn/js/app.map", "", "../../src/a.js", "../src/a.js"},
+		{"http://cdn/js/app.map", "/the/root", "src/a.js", "src/a.js"},
+	})
+}
+
+func TestWebpackResolver(t *testing.T) {
+	testResolver(t, sourcemap.WebpackResolver, []resolverTest{
+		{"http://cdn/js/app.map", "", "webpack:///./src/a.js", "src/a.js"},
+		{"http://cdn/js/app.map", "", "webpack://my-app/./src/a.js", "src/a.js"},
+		{"http://cdn/js/app.map", "", "webpack:///webpack/bootstrap", "webpack/bootstrap"},
+		{"http://cdn/js/app.map", "", "webpack:///node_modules/lib/index.js", "node_modules/lib/index.js"},
+		{"http://localhost:5173/src/a.js.map", "", "/@fs/home/app/src/a.js", "/home/app/src/a.js"},
+		{"http://cdn/js/app.map", "", "a.js", "http://cdn/js/a.js"},
+	})
+}
+
+func TestWithSourcePathResolver(t *testing.T) {
+	resolver := sourcemap.SourcePathResolverFunc(func(mapURL, sourceRoot, source string) (string, error) {
+		s, err := sourcemap.URLResolver.ResolveSource(mapURL, sourceRoot, source)
+		return strings.TrimPrefix(s, "/the/root/"), err
+	})
+	smap, err := sourcemap.Parse("", []byte(sourceMapJSON), sourcemap.WithSourcePathResolver(resolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []*sourceMapTest{
+		{1, 1, "one.js", "", 1, 1},
+		{2, 1, "two.js", "", 1, 1},
+	}
+	for _, test := range tests {
+		test.assert(t, smap)
+	}
+	if content := smap.SourceContent("one.js"); content != oneSourceContent {
+		t.Fatalf("got %q", content)
+	}
+
+	errResolver := sourcemap.SourcePathResolverFunc(func(_, _, _ string) (string, error) {
+		return "", errors.New("resolver failed")
+	})
+	_, err = sourcemap.Parse("", []byte(sourceMapJSON), sourcemap.WithSourcePathResolver(errResolver))
+	if err == nil || err.Error() != "resolver failed" {
+		t.Fatalf("got error %v", err)
+	}
+}
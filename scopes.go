@@ -0,0 +1,541 @@
+package sourcemap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap/base64vlq"
+)
+
+// OriginalScope is a scope in an original source, such as a function
+// or a block, as described by the scopes field of the map.
+// Lines are 1-based and columns are 0-based as in Source.
+type OriginalScope struct {
+	Source                 string
+	StartLine, StartColumn int
+	EndLine, EndColumn     int
+	// Name is the name of the function, which is empty for other scopes.
+	Name string
+	// Kind is the kind of the scope, such as "global" or "function".
+	Kind string
+	// IsStackFrame reports whether the scope is a function
+	// that appears in stack traces.
+	IsStackFrame bool
+	// IsHidden reports whether the scope should be hidden in stack traces.
+	IsHidden bool
+	// Variables holds the names of the variables declared in the scope.
+	Variables []string
+
+	Parent   *OriginalScope
+	Children []*OriginalScope
+}
+
+// GeneratedRange is a range of the generated code that implements
+// an original scope, as described by the scopes field of the map.
+// The range starts at the start position and ends before the end
+// position. Lines are 1-based and columns are 0-based as in Source.
+type GeneratedRange struct {
+	StartLine, StartColumn int
+	EndLine, EndColumn     int
+	// Definition is the scope that the range implements or nil.
+	Definition *OriginalScope
+	// IsStackFrame reports whether the range is a function
+	// in the generated code.
+	IsStackFrame bool
+	// IsHidden reports whether the range should be hidden in stack traces.
+	IsHidden bool
+	// Bindings holds the bindings of every variable of Definition.
+	Bindings [][]Binding
+	// CallSite is the position of the call that was inlined into
+	// the range or nil if the range is not inlined.
+	CallSite *CallSite
+
+	Parent   *GeneratedRange
+	Children []*GeneratedRange
+}
+
+// Binding is the expression in the generated code that holds the value
+// of a variable from the position on until the next binding.
+// The first binding of a variable starts at the start of its range.
+type Binding struct {
+	Line, Column int
+	// Expression is empty if the value is unavailable.
+	Expression string
+}
+
+// CallSite is a position in an original source.
+type CallSite struct {
+	Source       string
+	Line, Column int
+}
+
+// Tags of the items of the scopes field.
+const (
+	tagOriginalScopeStart      = 0x1
+	tagOriginalScopeEnd        = 0x2
+	tagOriginalScopeVariables  = 0x3
+	tagGeneratedRangeStart     = 0x4
+	tagGeneratedRangeEnd       = 0x5
+	tagGeneratedRangeBindings  = 0x6
+	tagGeneratedRangeSubrange  = 0x7
+	tagGeneratedRangeCallSite  = 0x8
+	originalScopeHasName       = 0x1
+	originalScopeHasKind       = 0x2
+	originalScopeIsStackFrame  = 0x4
+	originalScopeIsHidden      = 0x8
+	generatedRangeHasLine      = 0x1
+	generatedRangeHasDef       = 0x2
+	generatedRangeIsStackFrame = 0x4
+	generatedRangeIsHidden     = 0x8
+)
+
+type scopes struct {
+	m *sourceMap
+
+	// originals holds the top-level scope of every source, which is nil
+	// for sources without scopes, and all holds every scope by index.
+	originals []*OriginalScope
+	all       []*OriginalScope
+	ranges    []*GeneratedRange
+
+	scopeStack []*OriginalScope
+	rangeStack []*GeneratedRange
+
+	// source is the source of the next top-level scope.
+	source int
+	// The previous values that the items are relative to.
+	line, column         int
+	name, kind, variable int32
+	genLine, genColumn   int
+	definition           int32
+
+	rd  *strings.Reader
+	dec base64vlq.Decoder
+}
+
+// parseScopes decodes the scopes field.
+func parseScopes(m *sourceMap) (*scopes, error) {
+	rd := strings.NewReader("")
+	s := &scopes{
+		m:         m,
+		originals: make([]*OriginalScope, len(m.Sources)),
+		genLine:   1,
+		rd:        rd,
+		dec:       base64vlq.NewDecoder(rd),
+	}
+	for i, item := range strings.Split(m.Scopes, ",") {
+		if err := s.item(item); err != nil {
+			return nil, fmt.Errorf("sourcemap: scopes item=%d: %w", i, err)
+		}
+	}
+	if len(s.scopeStack) > 0 || len(s.rangeStack) > 0 {
+		return nil, fmt.Errorf("sourcemap: scopes have unclosed scopes or ranges")
+	}
+	return s, nil
+}
+
+func (s *scopes) unsigned() (int, error) {
+	n, err := s.dec.DecodeUnsigned()
+	if err != nil {
+		return 0, fmt.Errorf("truncated item")
+	}
+	return int(n), nil
+}
+
+func (s *scopes) signed() (int32, error) {
+	n, err := s.dec.Decode()
+	if err != nil {
+		return 0, fmt.Errorf("truncated item")
+	}
+	return n, nil
+}
+
+// nameAt returns the name at the index, which must be in range.
+func (s *scopes) nameAt(idx int32) (string, error) {
+	if idx < 0 || int(idx) >= len(s.m.Names) {
+		return "", fmt.Errorf("names index=%d is out of range", idx)
+	}
+	return s.m.name(int(idx)), nil
+}
+
+// binding decodes a names index that is offset by one,
+// where 0 means that the value is unavailable.
+func (s *scopes) binding() (string, error) {
+	n, err := s.unsigned()
+	if err != nil || n == 0 {
+		return "", err
+	}
+	return s.nameAt(int32(n - 1))
+}
+
+func (s *scopes) item(item string) error {
+	if item == "" {
+		// A source without scopes.
+		if len(s.scopeStack) == 0 && len(s.ranges) == 0 && len(s.rangeStack) == 0 {
+			s.source++
+		}
+		return nil
+	}
+	s.rd.Reset(item)
+	tag, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+
+	switch tag {
+	case tagOriginalScopeStart:
+		return s.scopeStart()
+	case tagOriginalScopeEnd:
+		return s.scopeEnd()
+	case tagOriginalScopeVariables:
+		if len(s.scopeStack) == 0 {
+			return fmt.Errorf("variables outside of a scope")
+		}
+		scope := s.scopeStack[len(s.scopeStack)-1]
+		for s.rd.Len() > 0 {
+			n, err := s.signed()
+			if err != nil {
+				return err
+			}
+			s.variable += n
+			name, err := s.nameAt(s.variable)
+			if err != nil {
+				return err
+			}
+			scope.Variables = append(scope.Variables, name)
+		}
+	case tagGeneratedRangeStart:
+		return s.rangeStart()
+	case tagGeneratedRangeEnd:
+		return s.rangeEnd()
+	case tagGeneratedRangeBindings:
+		r, err := s.currentRange()
+		if err != nil {
+			return err
+		}
+		for s.rd.Len() > 0 {
+			expr, err := s.binding()
+			if err != nil {
+				return err
+			}
+			r.Bindings = append(r.Bindings, []Binding{{
+				Line:       r.StartLine,
+				Column:     r.StartColumn,
+				Expression: expr,
+			}})
+		}
+	case tagGeneratedRangeSubrange:
+		return s.subrangeBinding()
+	case tagGeneratedRangeCallSite:
+		r, err := s.currentRange()
+		if err != nil {
+			return err
+		}
+		var v [3]int
+		for i := range v {
+			if v[i], err = s.unsigned(); err != nil {
+				return err
+			}
+		}
+		if v[0] >= len(s.m.Sources) || s.m.Sources[v[0]] == nil {
+			return fmt.Errorf("sources index=%d is out of range", v[0])
+		}
+		r.CallSite = &CallSite{
+			Source: *s.m.Sources[v[0]],
+			Line:   v[1] + 1,
+			Column: v[2],
+		}
+	}
+	// Items with unknown tags are skipped.
+	return nil
+}
+
+func (s *scopes) scopeStart() error {
+	flags, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+	line, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+	column, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+
+	var parent *OriginalScope
+	if len(s.scopeStack) > 0 {
+		parent = s.scopeStack[len(s.scopeStack)-1]
+	} else {
+		if s.source >= len(s.originals) {
+			return fmt.Errorf("more scopes than sources")
+		}
+		// Positions of a top-level scope are relative to the start of its source.
+		s.line, s.column = 1, 0
+	}
+	s.line += line
+	s.column = column
+	scope := &OriginalScope{
+		StartLine:    s.line,
+		StartColumn:  s.column,
+		IsStackFrame: flags&originalScopeIsStackFrame != 0,
+		IsHidden:     flags&originalScopeIsHidden != 0,
+		Parent:       parent,
+	}
+	if src := s.m.Sources[s.source]; src != nil {
+		scope.Source = *src
+	}
+	if flags&originalScopeHasName != 0 {
+		n, err := s.signed()
+		if err != nil {
+			return err
+		}
+		s.name += n
+		if scope.Name, err = s.nameAt(s.name); err != nil {
+			return err
+		}
+	}
+	if flags&originalScopeHasKind != 0 {
+		n, err := s.signed()
+		if err != nil {
+			return err
+		}
+		s.kind += n
+		if scope.Kind, err = s.nameAt(s.kind); err != nil {
+			return err
+		}
+	}
+
+	if parent != nil {
+		parent.Children = append(parent.Children, scope)
+	} else {
+		s.originals[s.source] = scope
+	}
+	s.all = append(s.all, scope)
+	s.scopeStack = append(s.scopeStack, scope)
+	return nil
+}
+
+func (s *scopes) scopeEnd() error {
+	if len(s.scopeStack) == 0 {
+		return fmt.Errorf("end of a scope that was not started")
+	}
+	line, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+	column, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+	s.line += line
+	s.column = column
+
+	scope := s.scopeStack[len(s.scopeStack)-1]
+	s.scopeStack = s.scopeStack[:len(s.scopeStack)-1]
+	scope.EndLine, scope.EndColumn = s.line, s.column
+	if len(s.scopeStack) == 0 {
+		s.source++
+	}
+	return nil
+}
+
+// genPosition moves the generated position by the line and column.
+// The column is relative to the previous one on the same line.
+func (s *scopes) genPosition(line, column int) {
+	if line > 0 {
+		s.genLine += line
+		s.genColumn = column
+	} else {
+		s.genColumn += column
+	}
+}
+
+func (s *scopes) rangeStart() error {
+	if len(s.scopeStack) > 0 {
+		return fmt.Errorf("range inside of a scope")
+	}
+	flags, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+	line := 0
+	if flags&generatedRangeHasLine != 0 {
+		if line, err = s.unsigned(); err != nil {
+			return err
+		}
+	}
+	column, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+	s.genPosition(line, column)
+
+	r := &GeneratedRange{
+		StartLine:    s.genLine,
+		StartColumn:  s.genColumn,
+		IsStackFrame: flags&generatedRangeIsStackFrame != 0,
+		IsHidden:     flags&generatedRangeIsHidden != 0,
+	}
+	if flags&generatedRangeHasDef != 0 {
+		n, err := s.signed()
+		if err != nil {
+			return err
+		}
+		s.definition += n
+		if s.definition < 0 || int(s.definition) >= len(s.all) {
+			return fmt.Errorf("scope index=%d is out of range", s.definition)
+		}
+		r.Definition = s.all[s.definition]
+	}
+
+	if len(s.rangeStack) > 0 {
+		r.Parent = s.rangeStack[len(s.rangeStack)-1]
+		r.Parent.Children = append(r.Parent.Children, r)
+	} else {
+		s.ranges = append(s.ranges, r)
+	}
+	s.rangeStack = append(s.rangeStack, r)
+	return nil
+}
+
+func (s *scopes) rangeEnd() error {
+	r, err := s.currentRange()
+	if err != nil {
+		return err
+	}
+	// The line is present if the item has two values.
+	var v []int
+	for s.rd.Len() > 0 {
+		n, err := s.unsigned()
+		if err != nil {
+			return err
+		}
+		v = append(v, n)
+	}
+	switch len(v) {
+	case 1:
+		s.genPosition(0, v[0])
+	case 2:
+		s.genPosition(v[0], v[1])
+	default:
+		return fmt.Errorf("end of a range has %d values", len(v))
+	}
+	r.EndLine, r.EndColumn = s.genLine, s.genColumn
+	s.rangeStack = s.rangeStack[:len(s.rangeStack)-1]
+	return nil
+}
+
+func (s *scopes) subrangeBinding() error {
+	r, err := s.currentRange()
+	if err != nil {
+		return err
+	}
+	variable, err := s.unsigned()
+	if err != nil {
+		return err
+	}
+	if variable >= len(r.Bindings) {
+		return fmt.Errorf("variable index=%d is out of range", variable)
+	}
+
+	line, column := r.StartLine, r.StartColumn
+	for s.rd.Len() > 0 {
+		expr, err := s.binding()
+		if err != nil {
+			return err
+		}
+		dLine, err := s.unsigned()
+		if err != nil {
+			return err
+		}
+		dColumn, err := s.unsigned()
+		if err != nil {
+			return err
+		}
+		if dLine > 0 {
+			line += dLine
+			column = dColumn
+		} else {
+			column += dColumn
+		}
+		r.Bindings[variable] = append(r.Bindings[variable], Binding{
+			Line:       line,
+			Column:     column,
+			Expression: expr,
+		})
+	}
+	return nil
+}
+
+func (s *scopes) currentRange() (*GeneratedRange, error) {
+	if len(s.rangeStack) == 0 {
+		return nil, fmt.Errorf("item outside of a range")
+	}
+	return s.rangeStack[len(s.rangeStack)-1], nil
+}
+
+// moveRange moves the positions of the range and its children
+// by the offset of its section.
+func moveRange(r *GeneratedRange, line, column int) {
+	move := func(l, c *int) {
+		if *l == 1 {
+			*c += column
+		}
+		*l += line
+	}
+	move(&r.StartLine, &r.StartColumn)
+	move(&r.EndLine, &r.EndColumn)
+	for _, bindings := range r.Bindings {
+		for i := range bindings {
+			move(&bindings[i].Line, &bindings[i].Column)
+		}
+	}
+	for _, child := range r.Children {
+		moveRange(child, line, column)
+	}
+}
+
+// OriginalScope returns the top-level scope of the source,
+// or nil if the map has no scopes for the source.
+func (c *Consumer) OriginalScope(source string) *OriginalScope {
+	return c.originalScopes[source]
+}
+
+// GeneratedRanges returns the top-level generated ranges
+// in generated order.
+func (c *Consumer) GeneratedRanges() []*GeneratedRange {
+	return c.ranges
+}
+
+// ScopesAt returns the generated ranges that contain the generated
+// position from the outermost to the innermost one, or nil.
+//
+// A debugger shows the variables of the Definition of the innermost
+// range using its Bindings. A range with a CallSite is a function that
+// was inlined at the call site, so it becomes a stack frame of its own,
+// whose caller is the innermost enclosing range that is a stack frame.
+func (c *Consumer) ScopesAt(genLine, genColumn int) []*GeneratedRange {
+	var found []*GeneratedRange
+	ranges := c.ranges
+	for {
+		i := 0
+		for ; i < len(ranges); i++ {
+			if ranges[i].contains(genLine, genColumn) {
+				break
+			}
+		}
+		if i == len(ranges) {
+			return found
+		}
+		found = append(found, ranges[i])
+		ranges = ranges[i].Children
+	}
+}
+
+func (r *GeneratedRange) contains(line, column int) bool {
+	afterStart := r.StartLine < line || r.StartLine == line && r.StartColumn <= column
+	beforeEnd := line < r.EndLine || line == r.EndLine && column < r.EndColumn
+	return afterStart && beforeEnd
+}
@@ -0,0 +1,126 @@
+package sourcemap_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+// scopesMapJSON describes the original code
+//
+//	function f(x) {
+//	  return x + 1;
+//	}
+//	f(2);
+//
+// and the generated code
+//
+//	function f(a){return a+1}
+//	console.log(2+1)
+//
+// where the call of f is inlined into 2+1.
+const scopesMapJSON = `{
+  "version": 3,
+  "sources": ["a.js"],
+  "names": ["f", "x", "global", "function", "a", "b"],
+  "mappings": "",
+  "scopes": "BCAAE,DA,BHAKAC,DC,CCB,CBF,ECAA,GB,EGKC,GF,FP,EDBMA,GA,HAGAB,IADA,FD,FB"
+}`
+
+func TestScopes(t *testing.T) {
+	smap, err := sourcemap.Parse("", []byte(scopesMapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	global := smap.OriginalScope("a.js")
+	if global == nil || len(global.Children) != 1 {
+		t.Fatalf("got %+v", global)
+	}
+	f := global.Children[0]
+	if global.Kind != "global" || global.EndLine != 4 || global.EndColumn != 5 ||
+		!reflect.DeepEqual(global.Variables, []string{"f"}) {
+		t.Errorf("got global scope %+v", global)
+	}
+	if f.Name != "f" || f.Kind != "function" || !f.IsStackFrame || f.Parent != global ||
+		f.StartLine != 1 || f.StartColumn != 10 || f.EndLine != 3 || f.EndColumn != 1 ||
+		!reflect.DeepEqual(f.Variables, []string{"x"}) {
+		t.Errorf("got function scope %+v", f)
+	}
+
+	ranges := smap.GeneratedRanges()
+	if len(ranges) != 1 || len(ranges[0].Children) != 2 {
+		t.Fatalf("got %+v", ranges)
+	}
+	inlined := ranges[0].Children[1]
+	if inlined.Definition != f || inlined.StartLine != 2 || inlined.StartColumn != 12 ||
+		inlined.EndLine != 2 || inlined.EndColumn != 15 {
+		t.Errorf("got inlined range %+v", inlined)
+	}
+	if !reflect.DeepEqual(inlined.CallSite, &sourcemap.CallSite{Source: "a.js", Line: 4, Column: 0}) {
+		t.Errorf("got call site %+v", inlined.CallSite)
+	}
+	wantedBindings := [][]sourcemap.Binding{{
+		{Line: 2, Column: 12, Expression: ""},
+		{Line: 2, Column: 13, Expression: "b"},
+	}}
+	if !reflect.DeepEqual(inlined.Bindings, wantedBindings) {
+		t.Errorf("got bindings %+v, wanted %+v", inlined.Bindings, wantedBindings)
+	}
+
+	tests := []struct {
+		genLine, genColumn int
+		wanted             []*sourcemap.GeneratedRange
+	}{
+		{1, 0, ranges},
+		{1, 12, []*sourcemap.GeneratedRange{ranges[0], ranges[0].Children[0]}},
+		{2, 13, []*sourcemap.GeneratedRange{ranges[0], inlined}},
+		{2, 15, ranges},
+		{3, 0, nil},
+	}
+	for _, test := range tests {
+		got := smap.ScopesAt(test.genLine, test.genColumn)
+		if !reflect.DeepEqual(got, test.wanted) {
+			t.Errorf("%d:%d: got %d ranges, wanted %d",
+				test.genLine, test.genColumn, len(got), len(test.wanted))
+		}
+	}
+}
+
+func TestScopesIndexMap(t *testing.T) {
+	jsonStr := `{
+  "version": 3,
+  "sections": [{"offset": {"line": 2, "column": 3}, "map": ` + scopesMapJSON + `}]
+}`
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := smap.GeneratedRanges()[0].Children[0]
+	if r.StartLine != 3 || r.StartColumn != 13 || r.EndLine != 3 || r.EndColumn != 28 {
+		t.Errorf("got %+v", r)
+	}
+	if got := smap.ScopesAt(4, 13); len(got) != 2 || got[1].CallSite == nil {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestScopesError(t *testing.T) {
+	for _, scopes := range []string{
+		// The scope is not closed.
+		"BCAAE",
+		// The range is not started.
+		"FA",
+		// The name is out of range.
+		"BBAAM,CAA",
+		// There is one source only.
+		"BAAA,CAA,BAAA,CAA",
+	} {
+		jsonStr := strings.Replace(scopesMapJSON, "BCAAE,DA,BHAKAC,DC,CCB,CBF,ECAA,GB,EGKC,GF,FP,EDBMA,GA,HAGAB,IADA,FD,FB", scopes, 1)
+		if _, err := sourcemap.Parse("", []byte(jsonStr)); err == nil {
+			t.Errorf("%s: wanted an error", scopes)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package sourcemap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+// specTestsDir holds the fixtures of https://github.com/tc39/source-map-tests.
+// It defaults to the subset in testdata; pass a checkout of the upstream
+// repository to run the complete suite:
+//
+//	go test -run TestSourceMapSpec -args -spec-tests=path/to/source-map-tests
+var specTestsDir = flag.String("spec-tests", "testdata/source-map-tests",
+	"directory of the source map conformance tests")
+
+// knownSpecFailures lists the conformance tests that fail
+// with the reason why. A test that starts to pass must be removed.
+var knownSpecFailures = map[string]string{
+	"versionMissing":              "a missing version is accepted",
+	"namesNotString":              "names that are not strings are accepted",
+	"indexMapMissingOffset":       "a missing section offset defaults to 0:0",
+	"indexMapInvalidBaseMappings": "the mappings field of an index map is ignored",
+	"indexMapOverlappingSections": "overlapping sections are accepted",
+	"indexMapUnorderedSections":   "unordered sections are accepted",
+
+	"invalidMappingSegmentWithZeroFields": "empty segments are skipped",
+
+	"basicMappingUnmappedLine": "Consumer.Source falls back to the last mapping of a previous line",
+}
+
+var errUnsupportedAction = errors.New("unsupported action")
+
+type specTestSuite struct {
+	Tests []specTest `json:"tests"`
+}
+
+type specTest struct {
+	Name             string           `json:"name"`
+	Description      string           `json:"description"`
+	BaseFile         string           `json:"baseFile"`
+	SourceMapFile    string           `json:"sourceMapFile"`
+	SourceMapIsValid bool             `json:"sourceMapIsValid"`
+	TestActions      []specTestAction `json:"testActions"`
+}
+
+type specTestAction struct {
+	ActionType      string   `json:"actionType"`
+	GeneratedLine   int      `json:"generatedLine"`
+	GeneratedColumn int      `json:"generatedColumn"`
+	OriginalSource  *string  `json:"originalSource"`
+	OriginalLine    int      `json:"originalLine"`
+	OriginalColumn  int      `json:"originalColumn"`
+	MappedName      *string  `json:"mappedName"`
+	Present         []string `json:"present"`
+}
+
+func TestSourceMapSpec(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join(*specTestsDir, "source-map-spec-tests.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var suite specTestSuite
+	if err := json.Unmarshal(b, &suite); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range suite.Tests {
+		test := test
+		t.Run(test.Name, func(t *testing.T) {
+			err := test.run()
+			reason, known := knownSpecFailures[test.Name]
+			switch {
+			case errors.Is(err, errUnsupportedAction):
+				t.Skip(err)
+			case err != nil && known:
+				t.Skipf("known failure, %s: %s", reason, err)
+			case err != nil:
+				t.Fatalf("%s: %s", test.Description, err)
+			case known:
+				t.Fatalf("the test passes now, remove it from knownSpecFailures")
+			}
+		})
+	}
+}
+
+func (test *specTest) run() error {
+	b, err := os.ReadFile(filepath.Join(*specTestsDir, "resources", test.SourceMapFile))
+	if err != nil {
+		return err
+	}
+
+	smap, err := sourcemap.Parse(test.SourceMapFile, b)
+	if !test.SourceMapIsValid {
+		if err == nil {
+			return errors.New("invalid source map is accepted")
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("valid source map is rejected: %w", err)
+	}
+
+	for i := range test.TestActions {
+		if err := test.TestActions[i].run(smap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (action *specTestAction) run(smap *sourcemap.Consumer) error {
+	switch action.ActionType {
+	case "checkMapping":
+		return action.checkMapping(smap)
+	case "checkIgnoreList":
+		return action.checkIgnoreList(smap)
+	default:
+		return fmt.Errorf("%w %q", errUnsupportedAction, action.ActionType)
+	}
+}
+
+func (action *specTestAction) checkMapping(smap *sourcemap.Consumer) error {
+	// The tests use 0-based lines.
+	source, name, line, col, ok := smap.Source(action.GeneratedLine+1, action.GeneratedColumn)
+	pos := fmt.Sprintf("%d:%d", action.GeneratedLine, action.GeneratedColumn)
+
+	if action.OriginalSource == nil {
+		if ok && source != "" {
+			return fmt.Errorf("%s: got %s:%d:%d, wanted no mapping", pos, source, line-1, col)
+		}
+		return nil
+	}
+
+	if !ok {
+		return fmt.Errorf("%s: mapping not found", pos)
+	}
+
+	var wantedName string
+	if action.MappedName != nil {
+		wantedName = *action.MappedName
+	}
+	if source != *action.OriginalSource ||
+		line-1 != action.OriginalLine ||
+		col != action.OriginalColumn ||
+		name != wantedName {
+		return fmt.Errorf("%s: got %s:%d:%d %q, wanted %s:%d:%d %q", pos,
+			source, line-1, col, name,
+			*action.OriginalSource, action.OriginalLine, action.OriginalColumn, wantedName)
+	}
+	return nil
+}
+
+func (action *specTestAction) checkIgnoreList(smap *sourcemap.Consumer) error {
+	ignored := []string{}
+	for _, si := range smap.Sources() {
+		if si.Ignored && !si.Null {
+			ignored = append(ignored, si.URL)
+		}
+	}
+
+	present := append([]string{}, action.Present...)
+	sort.Strings(present)
+	sort.Strings(ignored)
+	if !reflect.DeepEqual(ignored, present) {
+		return fmt.Errorf("ignore list: got %q, wanted %q", ignored, present)
+	}
+	return nil
+}
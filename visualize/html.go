@@ -0,0 +1,91 @@
+package visualize
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+type htmlSource struct {
+	Name  string
+	Lines [][]piece
+}
+
+type htmlPage struct {
+	File      string
+	Generated [][]piece
+	Sources   []htmlSource
+}
+
+// HTML writes a standalone HTML page that shows the generated code next
+// to the original sources. Every generated segment and the original span
+// it maps to have the same color, and hovering one highlights the other.
+// The sources are shown if their content is available.
+func HTML(w io.Writer, smap *sourcemap.Consumer, generated string) error {
+	m := newModel(smap, generated)
+
+	page := htmlPage{
+		File:      smap.File(),
+		Generated: make([][]piece, len(m.genLines)),
+	}
+	if page.File == "" {
+		page.File = "generated"
+	}
+	for i, line := range m.genLines {
+		page.Generated[i] = pieces(line, m.segments[i])
+	}
+	for _, src := range m.sources {
+		hs := htmlSource{
+			Name:  src.name,
+			Lines: make([][]piece, len(src.lines)),
+		}
+		for i, line := range src.lines {
+			hs.Lines[i] = origPieces(line, src.spans[i])
+		}
+		page.Sources = append(page.Sources, hs)
+	}
+
+	return htmlTemplate.Execute(w, &page)
+}
+
+var htmlTemplate = template.Must(template.New("visualize").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.File}}</title>
+<style>
+body { margin: 0; font-family: sans-serif; }
+.panes { display: flex; height: 100vh; }
+.pane { flex: 1; overflow: auto; border-right: 1px solid #ccc; }
+h2 { margin: 0; padding: 4px 8px; font-size: 14px; background: #eee; position: sticky; top: 0; }
+pre { margin: 0; padding: 4px 0; font-size: 12px; counter-reset: line; }
+.l { display: block; padding-left: 4.5em; text-indent: -4.5em; white-space: pre-wrap; word-break: break-all; }
+.l::before { counter-increment: line; content: counter(line); display: inline-block; width: 4em; margin-right: .5em; text-align: right; color: #999; }
+.c0 { background: #fde2e2; } .c1 { background: #fdf0d5; } .c2 { background: #e2f5e4; }
+.c3 { background: #dceefc; } .c4 { background: #ece2fa; } .c5 { background: #d9f3f2; }
+.hl { outline: 2px solid #e53935; }
+</style>
+</head>
+<body>
+<div class="panes">
+<div class="pane">
+<h2>{{.File}}</h2>
+<pre>{{range .Generated}}<span class="l">{{range .}}{{if ge .ID 0}}<span class="c{{.Color}}" data-o="{{.ID}}">{{.Text}}</span>{{else}}{{.Text}}{{end}}{{end}}</span>{{end}}</pre>
+</div>
+<div class="pane">
+{{range .Sources}}<h2>{{.Name}}</h2>
+<pre>{{range .Lines}}<span class="l">{{range .}}{{if ge .ID 0}}<span class="c{{.Color}}" data-o="{{.ID}}">{{.Text}}</span>{{else}}{{.Text}}{{end}}{{end}}</span>{{end}}</pre>
+{{end}}</div>
+</div>
+<script>
+document.addEventListener("mouseover", function (e) {
+  document.querySelectorAll(".hl").forEach(function (el) { el.classList.remove("hl"); });
+  var id = e.target.dataset && e.target.dataset.o;
+  if (id === undefined) return;
+  document.querySelectorAll('[data-o="' + id + '"]').forEach(function (el) { el.classList.add("hl"); });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,54 @@
+package visualize
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+// ansiColors are the background colors of the segments.
+var ansiColors = [numColors]string{
+	"\x1b[41m", "\x1b[43m", "\x1b[42m", "\x1b[44m", "\x1b[45m", "\x1b[46m",
+}
+
+const ansiReset = "\x1b[0m"
+
+// Terminal writes the generated code followed by the original sources
+// using ANSI escape codes. Every generated segment and the original span
+// it maps to have the same color, and both are prefixed with the ID of
+// the original span in brackets, so they can be matched when colors repeat.
+// The sources are shown if their content is available.
+func Terminal(w io.Writer, smap *sourcemap.Consumer, generated string) error {
+	m := newModel(smap, generated)
+	bw := bufio.NewWriter(w)
+
+	file := smap.File()
+	if file == "" {
+		file = "generated"
+	}
+	fmt.Fprintf(bw, "== %s ==\n", file)
+	for i, line := range m.genLines {
+		writeTerminalLine(bw, i+1, pieces(line, m.segments[i]))
+	}
+	for _, src := range m.sources {
+		fmt.Fprintf(bw, "\n== %s ==\n", src.name)
+		for i, line := range src.lines {
+			writeTerminalLine(bw, i+1, origPieces(line, src.spans[i]))
+		}
+	}
+	return bw.Flush()
+}
+
+func writeTerminalLine(w *bufio.Writer, n int, ps []piece) {
+	fmt.Fprintf(w, "%5d | ", n)
+	for _, p := range ps {
+		if p.ID < 0 {
+			w.WriteString(p.Text)
+			continue
+		}
+		fmt.Fprintf(w, "%s[%d]%s%s", ansiColors[p.Color], p.ID, p.Text, ansiReset)
+	}
+	w.WriteByte('\n')
+}
@@ -0,0 +1,181 @@
+// Package visualize renders the mappings of a source map, showing every
+// segment of the generated code together with the span of the original
+// source it maps to.
+package visualize
+
+import (
+	"sort"
+
+	"github.com/go-sourcemap/sourcemap"
+	"github.com/go-sourcemap/sourcemap/internal/linecol"
+)
+
+// numColors is the number of colors that segments cycle through.
+const numColors = 6
+
+// segment is a span of a generated line. Offsets are in bytes.
+type segment struct {
+	start, end int
+	// orig is nil if the segment is unmapped or the content
+	// of its source is not available.
+	orig *origSpan
+}
+
+// origSpan is a span of an original line that one or more segments map to.
+// It runs up to the next mapped column on the line. Offsets are in bytes.
+type origSpan struct {
+	id         int
+	line       int
+	start, end int
+}
+
+func (s *origSpan) color() int {
+	return s.id % numColors
+}
+
+type source struct {
+	name  string
+	lines []string
+	// spans holds the spans of every line sorted by offset.
+	spans [][]*origSpan
+}
+
+type model struct {
+	genLines []string
+	// segments holds the segments of every generated line.
+	segments [][]segment
+	sources  []*source
+}
+
+type origKey struct {
+	source       string
+	line, column int
+}
+
+func newModel(smap *sourcemap.Consumer, generated string) *model {
+	m := &model{
+		genLines: linecol.Split(generated),
+	}
+	m.segments = make([][]segment, len(m.genLines))
+
+	var mappings []sourcemap.Mapping
+	smap.EachMapping(func(v sourcemap.Mapping) bool {
+		if v.GenLine >= 1 && v.GenLine <= len(m.genLines) {
+			mappings = append(mappings, v)
+		}
+		return true
+	})
+
+	sources := make(map[string]*source)
+	spans := make(map[origKey]*origSpan)
+	for i, v := range mappings {
+		line := m.genLines[v.GenLine-1]
+		seg := segment{
+			start: linecol.ByteOffset(line, v.GenColumn),
+			end:   len(line),
+		}
+		if i+1 < len(mappings) && mappings[i+1].GenLine == v.GenLine {
+			seg.end = linecol.ByteOffset(line, mappings[i+1].GenColumn)
+		}
+		if seg.start >= seg.end {
+			continue
+		}
+
+		if v.Source != "" {
+			src, ok := sources[v.Source]
+			if !ok {
+				if content, ok := smap.LookupSourceContent(v.Source); ok {
+					src = &source{
+						name:  v.Source,
+						lines: linecol.Split(content),
+					}
+					src.spans = make([][]*origSpan, len(src.lines))
+					m.sources = append(m.sources, src)
+				}
+				sources[v.Source] = src
+			}
+			if src != nil && v.Line >= 1 && v.Line <= len(src.lines) {
+				k := origKey{v.Source, v.Line, v.Column}
+				span, ok := spans[k]
+				if !ok {
+					span = &origSpan{
+						id:    len(spans),
+						line:  v.Line,
+						start: linecol.ByteOffset(src.lines[v.Line-1], v.Column),
+					}
+					spans[k] = span
+					src.spans[v.Line-1] = append(src.spans[v.Line-1], span)
+				}
+				seg.orig = span
+			}
+		}
+		m.segments[v.GenLine-1] = append(m.segments[v.GenLine-1], seg)
+	}
+
+	for _, src := range m.sources {
+		for i, line := range src.spans {
+			sort.Slice(line, func(i, j int) bool {
+				return line[i].start < line[j].start
+			})
+			for j, span := range line {
+				span.end = len(src.lines[i])
+				if j+1 < len(line) {
+					span.end = line[j+1].start
+				}
+			}
+		}
+	}
+	sort.Slice(m.sources, func(i, j int) bool {
+		return m.sources[i].name < m.sources[j].name
+	})
+	return m
+}
+
+// piece is a part of a line that is rendered in one style.
+type piece struct {
+	Text string
+	// ID is the ID of the original span, which is -1 for text
+	// that is not part of a mapped segment or span.
+	ID    int
+	Color int
+}
+
+// pieces splits the line at the segments, which are sorted by offset.
+func pieces(line string, segments []segment) []piece {
+	var ps []piece
+	pos := 0
+	for _, seg := range segments {
+		if seg.start < pos {
+			continue
+		}
+		if seg.start > pos {
+			ps = append(ps, piece{Text: line[pos:seg.start], ID: -1})
+		}
+		p := piece{Text: line[seg.start:seg.end], ID: -1}
+		if seg.orig != nil {
+			p.ID = seg.orig.id
+			p.Color = seg.orig.color()
+		}
+		ps = append(ps, p)
+		pos = seg.end
+	}
+	if pos < len(line) {
+		ps = append(ps, piece{Text: line[pos:], ID: -1})
+	}
+	return ps
+}
+
+// origPieces splits the original line at the spans.
+func origPieces(line string, spans []*origSpan) []piece {
+	segments := make([]segment, 0, len(spans))
+	for _, span := range spans {
+		if span.start < span.end {
+			segments = append(segments, segment{
+				start: span.start,
+				end:   span.end,
+				orig:  span,
+			})
+		}
+	}
+	return pieces(line, segments)
+}
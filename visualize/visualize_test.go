@@ -0,0 +1,70 @@
+package visualize_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+	"github.com/go-sourcemap/sourcemap/visualize"
+)
+
+const generated = "var a=1;\nb(a);"
+
+const mapJSON = `{
+  "version": 3,
+  "file": "min.js",
+  "sources": ["app.js", "lib.js"],
+  "sourcesContent": ["let first = 1;", null],
+  "names": ["first"],
+  "mappings": "AAAA,IAAIA,EAAQ,C;ACAA"
+}`
+
+func parse(t *testing.T) *sourcemap.Consumer {
+	t.Helper()
+	smap, err := sourcemap.Parse("", []byte(mapJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return smap
+}
+
+func TestTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := visualize.Terminal(&buf, parse(t), generated); err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := "== min.js ==\n" +
+		"    1 | \x1b[41m[0]var \x1b[0m\x1b[43m[1]a=\x1b[0m\x1b[42m[2]1\x1b[0m;\n" +
+		"    2 | b(a);\n" +
+		"\n== app.js ==\n" +
+		"    1 | \x1b[41m[0]let \x1b[0m\x1b[43m[1]first = \x1b[0m\x1b[42m[2]1;\x1b[0m\n"
+	if got := buf.String(); got != wanted {
+		t.Fatalf("got\n%q, wanted\n%q", got, wanted)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := visualize.HTML(&buf, parse(t), generated); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, s := range []string{
+		`<title>min.js</title>`,
+		`<span class="l"><span class="c0" data-o="0">var </span><span class="c1" data-o="1">a=</span>` +
+			`<span class="c2" data-o="2">1</span>;</span>`,
+		`<span class="l">b(a);</span>`,
+		`<h2>app.js</h2>`,
+		`<span class="c1" data-o="1">first = </span><span class="c2" data-o="2">1;</span>`,
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("%s is missing in\n%s", s, got)
+		}
+	}
+	if strings.Contains(got, "lib.js") {
+		t.Error("a source without content is shown")
+	}
+}
@@ -0,0 +1,77 @@
+package sourcemap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var wasmMagic = []byte("\x00asm")
+
+// WasmSourceMappingURL returns the URL of the map of a WebAssembly module,
+// which is stored in its sourceMappingURL custom section.
+// It returns "" if the module has no such section.
+func WasmSourceMappingURL(module []byte) (string, error) {
+	if len(module) < 8 || !bytes.Equal(module[:4], wasmMagic) {
+		return "", errors.New("sourcemap: not a wasm module")
+	}
+	b := module[8:]
+	for len(b) > 0 {
+		id := b[0]
+		size, n := uleb128(b[1:])
+		if n == 0 || uint64(len(b)-1-n) < size {
+			return "", fmt.Errorf("sourcemap: wasm section at offset=%d is truncated", len(module)-len(b))
+		}
+		payload := b[1+n : 1+n+int(size)]
+		b = b[1+n+int(size):]
+
+		// Custom sections have id 0 and start with their name.
+		if id != 0 {
+			continue
+		}
+		name, rest, ok := wasmString(payload)
+		if !ok {
+			return "", errors.New("sourcemap: wasm custom section has a truncated name")
+		}
+		if name != "sourceMappingURL" {
+			continue
+		}
+		url, _, ok := wasmString(rest)
+		if !ok {
+			return "", errors.New("sourcemap: wasm sourceMappingURL section is truncated")
+		}
+		return url, nil
+	}
+	return "", nil
+}
+
+// wasmString decodes a string prefixed by its length.
+func wasmString(b []byte) (s string, rest []byte, ok bool) {
+	size, n := uleb128(b)
+	if n == 0 || uint64(len(b)-n) < size {
+		return "", nil, false
+	}
+	return string(b[n : n+int(size)]), b[n+int(size):], true
+}
+
+// uleb128 decodes an unsigned 32-bit LEB128 number and returns
+// the number of bytes read, which is 0 if the number is invalid.
+func uleb128(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 5; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// SourceForWasmOffset is like Source for a byte offset in a WebAssembly
+// module, as reported by wasm stack frames. Wasm maps have a single line
+// whose columns are the offsets.
+func (c *Consumer) SourceForWasmOffset(
+	offset int,
+) (source, name string, line, column int, ok bool) {
+	return c.Source(1, offset)
+}
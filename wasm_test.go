@@ -0,0 +1,55 @@
+package sourcemap_test
+
+import (
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestWasmSourceMappingURL(t *testing.T) {
+	header := "\x00asm\x01\x00\x00\x00"
+	// A type section and the custom sections "name" and "sourceMappingURL".
+	typeSection := "\x01\x04\x01\x60\x00\x00"
+	nameSection := "\x00\x05\x04name"
+	url := "http://localhost/app.wasm.map"
+	urlSection := "\x00" + string(rune(1+16+1+len(url))) + "\x10sourceMappingURL" +
+		string(rune(len(url))) + url
+
+	tests := []struct {
+		module string
+		url    string
+		err    bool
+	}{
+		{header + typeSection + nameSection + urlSection, url, false},
+		{header + typeSection, "", false},
+		{header + typeSection[:4], "", true},
+		{"\x00asn\x01\x00\x00\x00", "", true},
+		{header + urlSection[:len(urlSection)-1], "", true},
+	}
+	for i, test := range tests {
+		got, err := sourcemap.WasmSourceMappingURL([]byte(test.module))
+		if got != test.url || (err != nil) != test.err {
+			t.Errorf("%d: got %q, %v, wanted %q", i, got, err, test.url)
+		}
+	}
+}
+
+func TestSourceForWasmOffset(t *testing.T) {
+	jsonStr := `{
+  "version": 3,
+  "sources": ["lib.rs"],
+  "names": [],
+  "mappings": "gCAAA,0BACC"
+}`
+	smap, err := sourcemap.Parse("", []byte(jsonStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, _, line, column, ok := smap.SourceForWasmOffset(0x3a)
+	if !ok || source != "lib.rs" || line != 2 || column != 1 {
+		t.Errorf("got %s:%d:%d, %v, wanted lib.rs:2:1", source, line, column, ok)
+	}
+	if _, _, _, _, ok := smap.SourceForWasmOffset(10); ok {
+		t.Error("the offset must not be mapped")
+	}
+}
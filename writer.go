@@ -0,0 +1,122 @@
+package sourcemap
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"github.com/go-sourcemap/sourcemap/internal/linecol"
+)
+
+// Writer writes generated code and tracks the position of the code
+// written, so that mappings can be added at the current position.
+// Lines are separated by "\n", "\r\n" and "\r" and columns are counted
+// in UTF-16 code units as in source maps.
+type Writer struct {
+	w   io.Writer
+	gen *StreamingGenerator
+
+	line, column int
+	// cr reports whether the last byte was '\r',
+	// which makes the next '\n' part of the same line break.
+	cr bool
+	// pending holds the start of a rune that is split between writes.
+	pending []byte
+}
+
+// NewWriter returns a writer of generated code to w
+// that adds the mappings to gen.
+func NewWriter(w io.Writer, gen *StreamingGenerator) *Writer {
+	return &Writer{
+		w:    w,
+		gen:  gen,
+		line: 1,
+	}
+}
+
+// Write writes the code and advances the position.
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.advance(p[:n])
+	return n, err
+}
+
+// WriteString is like Write for a string.
+func (w *Writer) WriteString(s string) (int, error) {
+	n, err := io.WriteString(w.w, s)
+	w.advance([]byte(s[:n]))
+	return n, err
+}
+
+// Position returns the current generated position. The line is 1-based
+// and the column is 0-based as in Source.
+func (w *Writer) Position() (line, column int) {
+	return w.line, w.column
+}
+
+// Mark adds a mapping from the current generated position to the
+// original position. Lines are 1-based and columns are 0-based as in
+// Source and name may be empty. An empty source marks the code that
+// follows as unmapped.
+func (w *Writer) Mark(source string, line, column int, name string) error {
+	return w.gen.AddMapping(Mapping{
+		GenLine:   w.line,
+		GenColumn: w.column,
+		Source:    source,
+		Name:      name,
+		Line:      line,
+		Column:    column,
+	})
+}
+
+func (w *Writer) advance(p []byte) {
+	// Complete the rune that the last write split.
+	for len(w.pending) > 0 && len(p) > 0 {
+		n, m := len(w.pending), len(p)
+		if m > utf8.UTFMax {
+			m = utf8.UTFMax
+		}
+		buf := append(w.pending[:n:n], p[:m]...)
+		if !utf8.FullRune(buf) {
+			w.pending = buf
+			return
+		}
+		r, size := utf8.DecodeRune(buf)
+		w.advanceRune(r)
+		if size < n {
+			// An invalid byte.
+			w.pending = w.pending[size:]
+			continue
+		}
+		p = p[size-n:]
+		w.pending = nil
+	}
+
+	for i := 0; i < len(p); {
+		if p[i] < utf8.RuneSelf {
+			w.advanceRune(rune(p[i]))
+			i++
+			continue
+		}
+		if !utf8.FullRune(p[i:]) {
+			w.pending = append(w.pending[:0], p[i:]...)
+			return
+		}
+		r, size := utf8.DecodeRune(p[i:])
+		w.advanceRune(r)
+		i += size
+	}
+}
+
+func (w *Writer) advanceRune(r rune) {
+	switch {
+	case r == '\n' && w.cr:
+		w.cr = false
+	case r == '\n' || r == '\r':
+		w.line++
+		w.column = 0
+		w.cr = r == '\r'
+	default:
+		w.cr = false
+		w.column += linecol.RuneWidth(r, 0, linecol.UTF16)
+	}
+}
@@ -0,0 +1,66 @@
+package sourcemap_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+func TestWriter(t *testing.T) {
+	var code, smap bytes.Buffer
+	g := sourcemap.NewStreamingGenerator(&smap, "out.js")
+	w := sourcemap.NewWriter(&code, g)
+
+	type pos struct{ line, column int }
+	var positions []pos
+	mark := func(line, column int, name string) {
+		t.Helper()
+		if err := w.Mark("in.tmpl", line, column, name); err != nil {
+			t.Fatal(err)
+		}
+		l, c := w.Position()
+		positions = append(positions, pos{l, c})
+	}
+
+	mark(1, 0, "")
+	w.WriteString("a\U0001F600")
+	mark(1, 2, "smile")
+	// The rune and "\r\n" are split between writes.
+	s := "é\r\nb\rc\n"
+	w.Write([]byte(s[:1]))
+	mark(1, 3, "")
+	w.Write([]byte(s[1:3]))
+	w.Write([]byte(s[3:]))
+	mark(2, 0, "")
+	// An invalid byte counts as a column.
+	w.Write([]byte("\xff"))
+	mark(2, 1, "")
+
+	wanted := []pos{{1, 0}, {1, 3}, {1, 3}, {4, 0}, {4, 1}}
+	for i := range wanted {
+		if positions[i] != wanted[i] {
+			t.Errorf("mark %d: got %v, wanted %v", i, positions[i], wanted[i])
+		}
+	}
+	if code.String() != "a\U0001F600"+s+"\xff" {
+		t.Errorf("got code %q", code.String())
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	consumer, err := sourcemap.Parse("", smap.Bytes(), sourcemap.WithSourcePathResolver(sourcemap.RawResolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The column after the emoji in the generated code, converted to runes.
+	source, name, line, column, ok := consumer.SourceIn(code.String(), 1, 2, sourcemap.Runes)
+	if !ok || source != "in.tmpl" || name != "smile" || line != 1 || column != 2 {
+		t.Errorf("got %s %s %d:%d %v", source, name, line, column, ok)
+	}
+	if !strings.Contains(smap.String(), `"names":["smile"]`) {
+		t.Errorf("got %s", smap.String())
+	}
+}